@@ -0,0 +1,96 @@
+package diagnostics
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+type Diagnostic struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+type List struct {
+	File   string
+	source string
+	Diags  []*Diagnostic
+}
+
+func NewList(file string, source string) *List {
+	return &List{
+		File:   file,
+		source: source,
+	}
+}
+
+func (l *List) Report(line int, column int, msg string) {
+	l.Diags = append(l.Diags, &Diagnostic{Line: line, Column: column, Msg: msg})
+}
+
+func (l *List) Reportf(line int, column int, format string, a ...any) {
+	l.Report(line, column, fmt.Sprintf(format, a...))
+}
+
+func (l *List) Len() int {
+	return len(l.Diags)
+}
+
+// Err returns the list as an error, or nil if nothing has been reported.
+func (l *List) Err() error {
+	if l.Len() == 0 {
+		return nil
+	}
+	return l
+}
+
+func (l *List) Error() string {
+	var sb strings.Builder
+	l.Print(&sb)
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func (l *List) Print(w io.Writer) {
+	for _, d := range l.Diags {
+		fmt.Fprint(w, l.Format(d))
+	}
+}
+
+// Format renders a diagnostic as `file:line:col: error: msg` followed by the
+// offending source line and a caret under the reported column.
+func (l *List) Format(d *Diagnostic) string {
+	text := fmt.Sprintf("%s:%d:%d: error: %s\n", l.File, d.Line, d.Column, d.Msg)
+	line, ok := l.line(d.Line)
+	if !ok {
+		return text
+	}
+	var caret strings.Builder
+	for i := 0; i < d.Column-1 && i < len(line); i++ {
+		if line[i] == '\t' {
+			caret.WriteByte('\t')
+		} else {
+			caret.WriteByte(' ')
+		}
+	}
+	caret.WriteByte('^')
+	return text + line + "\n" + caret.String() + "\n"
+}
+
+func (l *List) line(n int) (string, bool) {
+	rest := l.source
+	for i := 1; i < n; i++ {
+		next := strings.IndexByte(rest, '\n')
+		if next < 0 {
+			return "", false
+		}
+		rest = rest[next+1:]
+	}
+	if n < 1 {
+		return "", false
+	}
+	if end := strings.IndexByte(rest, '\n'); end >= 0 {
+		rest = rest[:end]
+	}
+	return strings.TrimSuffix(rest, "\r"), true
+}
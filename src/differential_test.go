@@ -0,0 +1,417 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/arregist97/Hydro-Compiler/diagnostics"
+	"github.com/arregist97/Hydro-Compiler/interp"
+	"github.com/arregist97/Hydro-Compiler/parser"
+	"github.com/arregist97/Hydro-Compiler/tokenizer"
+)
+
+var (
+	diffSeed  = flag.Int64("diff.seed", 1, "first seed used by TestDifferential")
+	diffCount = flag.Int("diff.count", 100, "number of programs TestDifferential generates")
+)
+
+// genStmt and genExpr are a small tree for generated programs. Keeping our own
+// tree instead of the parser's makes it easy to print and to shrink.
+type genStmt struct {
+	kind  string // let, assign, print, scope, if or exit
+	name  string
+	op    string // for assign: "", a compound operator such as "+", or "++"/"--"
+	expr  *genExpr
+	body  []*genStmt
+	elifs []*genClause
+	els   []*genStmt
+}
+
+type genClause struct {
+	cond *genExpr
+	body []*genStmt
+}
+
+type genExpr struct {
+	op    string // empty for literals and variables
+	val   int64
+	name  string
+	left  *genExpr
+	right *genExpr
+}
+
+var (
+	genNames     = []string{"a", "b", "c", "d"}
+	genAssignOps = []string{"", "", "+", "-", "*", "/", "%", "++", "--"}
+	genBinaryOps = []string{"+", "-", "*", "/", "%", "==", "!=", "<", "<=", ">", ">=", "&&", "||", "&", "|", "^", "<<", ">>"}
+)
+
+// progGen builds random programs that compile: every variable is declared
+// before use and every divisor is a positive literal.
+type progGen struct {
+	r      *rand.Rand
+	scopes [][]string
+}
+
+func (g *progGen) program() []*genStmt {
+	g.scopes = [][]string{nil}
+	stmts := g.block(0)
+	return append(stmts, &genStmt{kind: "exit", expr: g.expr(2)})
+}
+
+func (g *progGen) visible() []string {
+	var names []string
+	for _, scope := range g.scopes {
+		names = append(names, scope...)
+	}
+	return names
+}
+
+func (g *progGen) block(depth int) []*genStmt {
+	n := 1 + g.r.Intn(4)
+	stmts := make([]*genStmt, 0, n)
+	for i := 0; i < n; i++ {
+		stmts = append(stmts, g.stmt(depth))
+	}
+	return stmts
+}
+
+func (g *progGen) scope(depth int) []*genStmt {
+	g.scopes = append(g.scopes, nil)
+	stmts := g.block(depth + 1)
+	g.scopes = g.scopes[:len(g.scopes)-1]
+	return stmts
+}
+
+func (g *progGen) stmt(depth int) *genStmt {
+	choice := g.r.Intn(11)
+	if depth >= 3 {
+		choice = g.r.Intn(5)
+	}
+	names := g.visible()
+	switch {
+	case choice < 3 || (choice < 5 && len(names) == 0):
+		s := &genStmt{kind: "let", name: genNames[g.r.Intn(len(genNames))], expr: g.expr(2)}
+		inner := len(g.scopes) - 1
+		g.scopes[inner] = append(g.scopes[inner], s.name)
+		return s
+	case choice < 5:
+		s := &genStmt{kind: "assign", name: names[g.r.Intn(len(names))], op: genAssignOps[g.r.Intn(len(genAssignOps))]}
+		switch s.op {
+		case "++", "--":
+		case "/", "%":
+			s.expr = &genExpr{val: 1 + int64(g.r.Intn(9))}
+		default:
+			s.expr = g.expr(2)
+		}
+		return s
+	case choice < 7:
+		return &genStmt{kind: "scope", body: g.scope(depth)}
+	case choice < 9:
+		s := &genStmt{kind: "if", expr: g.expr(2), body: g.scope(depth)}
+		for n := g.r.Intn(3); n > 0; n-- {
+			s.elifs = append(s.elifs, &genClause{cond: g.expr(2), body: g.scope(depth)})
+		}
+		if g.r.Intn(2) == 0 {
+			s.els = g.scope(depth)
+		}
+		return s
+	case choice < 10:
+		return &genStmt{kind: "print", expr: g.expr(2)}
+	}
+	return &genStmt{kind: "exit", expr: g.expr(2)}
+}
+
+func (g *progGen) expr(depth int) *genExpr {
+	names := g.visible()
+	if depth <= 0 || g.r.Intn(3) == 0 {
+		if len(names) > 0 && g.r.Intn(2) == 0 {
+			return &genExpr{name: names[g.r.Intn(len(names))]}
+		}
+		return &genExpr{val: int64(g.r.Intn(300))}
+	}
+	switch g.r.Intn(8) {
+	case 0:
+		return &genExpr{op: "!", left: g.expr(depth - 1)}
+	case 1:
+		return &genExpr{op: "-", left: g.expr(depth - 1)}
+	case 2:
+		return &genExpr{op: "~", left: g.expr(depth - 1)}
+	}
+	op := genBinaryOps[g.r.Intn(len(genBinaryOps))]
+	if op == "/" || op == "%" {
+		return &genExpr{op: op, left: g.expr(depth - 1), right: &genExpr{val: 1 + int64(g.r.Intn(9))}}
+	}
+	return &genExpr{op: op, left: g.expr(depth - 1), right: g.expr(depth - 1)}
+}
+
+func formatProgram(stmts []*genStmt) string {
+	var sb strings.Builder
+	formatBlock(&sb, stmts, 0)
+	return sb.String()
+}
+
+func formatBlock(sb *strings.Builder, stmts []*genStmt, depth int) {
+	indent := strings.Repeat("    ", depth)
+	for _, s := range stmts {
+		switch s.kind {
+		case "let":
+			sb.WriteString(indent + "let " + s.name + " = " + formatExpr(s.expr) + "\n")
+		case "assign":
+			if s.expr == nil {
+				sb.WriteString(indent + s.name + s.op + "\n")
+			} else {
+				sb.WriteString(indent + s.name + " " + s.op + "= " + formatExpr(s.expr) + "\n")
+			}
+		case "exit", "print":
+			sb.WriteString(indent + s.kind + "(" + formatExpr(s.expr) + ")\n")
+		case "scope":
+			sb.WriteString(indent + "{\n")
+			formatBlock(sb, s.body, depth+1)
+			sb.WriteString(indent + "}\n")
+		case "if":
+			sb.WriteString(indent + "if " + formatExpr(s.expr) + " {\n")
+			formatBlock(sb, s.body, depth+1)
+			for _, elif := range s.elifs {
+				sb.WriteString(indent + "} elif " + formatExpr(elif.cond) + " {\n")
+				formatBlock(sb, elif.body, depth+1)
+			}
+			if s.els != nil {
+				sb.WriteString(indent + "} else {\n")
+				formatBlock(sb, s.els, depth+1)
+			}
+			sb.WriteString(indent + "}\n")
+		}
+	}
+}
+
+func formatExpr(e *genExpr) string {
+	switch {
+	case e.op == "":
+		if e.name != "" {
+			return e.name
+		}
+		return strconv.FormatInt(e.val, 10)
+	case e.right == nil:
+		return e.op + "(" + formatExpr(e.left) + ")"
+	}
+	return "(" + formatExpr(e.left) + " " + e.op + " " + formatExpr(e.right) + ")"
+}
+
+// shrinkStmts lists every program one step simpler than stmts: a statement
+// removed, an if or scope replaced by part of itself, or an expression
+// simplified. Some candidates no longer compile and are rejected by the caller.
+func shrinkStmts(stmts []*genStmt) [][]*genStmt {
+	var out [][]*genStmt
+	for i, s := range stmts {
+		out = append(out, splice(stmts, i))
+		for _, v := range shrinkStmt(s) {
+			out = append(out, splice(stmts, i, v...))
+		}
+	}
+	return out
+}
+
+func splice(stmts []*genStmt, i int, with ...*genStmt) []*genStmt {
+	out := make([]*genStmt, 0, len(stmts)+len(with))
+	out = append(out, stmts[:i]...)
+	out = append(out, with...)
+	return append(out, stmts[i+1:]...)
+}
+
+func shrinkStmt(s *genStmt) [][]*genStmt {
+	var out [][]*genStmt
+	with := func(f func(c *genStmt)) {
+		c := *s
+		f(&c)
+		out = append(out, []*genStmt{&c})
+	}
+	switch s.kind {
+	case "scope":
+		out = append(out, s.body)
+		for _, body := range shrinkStmts(s.body) {
+			with(func(c *genStmt) { c.body = body })
+		}
+	case "if":
+		out = append(out, []*genStmt{{kind: "scope", body: s.body}})
+		if s.els != nil {
+			out = append(out, []*genStmt{{kind: "scope", body: s.els}})
+			with(func(c *genStmt) { c.els = nil })
+		}
+		for i := range s.elifs {
+			with(func(c *genStmt) { c.elifs = append(append([]*genClause{}, s.elifs[:i]...), s.elifs[i+1:]...) })
+		}
+		for _, body := range shrinkStmts(s.body) {
+			with(func(c *genStmt) { c.body = body })
+		}
+		for _, els := range shrinkStmts(s.els) {
+			with(func(c *genStmt) { c.els = els })
+		}
+		for i, elif := range s.elifs {
+			for _, cond := range shrinkExpr(elif.cond) {
+				with(func(c *genStmt) { c.elifs = replaceClause(s.elifs, i, &genClause{cond: cond, body: elif.body}) })
+			}
+			for _, body := range shrinkStmts(elif.body) {
+				with(func(c *genStmt) { c.elifs = replaceClause(s.elifs, i, &genClause{cond: elif.cond, body: body}) })
+			}
+		}
+	}
+	if s.expr != nil {
+		for _, e := range shrinkExpr(s.expr) {
+			if (s.op == "/" || s.op == "%") && e.op == "" && e.name == "" && e.val == 0 {
+				continue
+			}
+			with(func(c *genStmt) { c.expr = e })
+		}
+	}
+	return out
+}
+
+func replaceClause(clauses []*genClause, i int, clause *genClause) []*genClause {
+	out := append([]*genClause{}, clauses...)
+	out[i] = clause
+	return out
+}
+
+func shrinkExpr(e *genExpr) []*genExpr {
+	if e.op == "" {
+		if e.name != "" {
+			return []*genExpr{{val: 0}, {val: 1}}
+		}
+		if e.val > 1 {
+			return []*genExpr{{val: 0}, {val: 1}, {val: e.val / 2}}
+		}
+		if e.val == 1 {
+			return []*genExpr{{val: 0}}
+		}
+		return nil
+	}
+	out := []*genExpr{{val: 0}, {val: 1}, e.left}
+	if e.right != nil && e.op != "/" && e.op != "%" {
+		out = append(out, e.right)
+	}
+	for _, left := range shrinkExpr(e.left) {
+		out = append(out, &genExpr{op: e.op, left: left, right: e.right})
+	}
+	if e.right != nil {
+		for _, right := range shrinkExpr(e.right) {
+			if (e.op == "/" || e.op == "%") && right.val == 0 {
+				continue
+			}
+			out = append(out, &genExpr{op: e.op, left: e.left, right: right})
+		}
+	}
+	return out
+}
+
+// outcome is everything a generated program can be observed doing.
+type outcome struct {
+	status int
+	stdout string
+}
+
+// compare builds src natively and with the interpreter. ok is false when src
+// does not compile, which rules it out as a shrinking candidate.
+func compare(t *testing.T, src string) (native outcome, interpreted outcome, ok bool) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "diff.hy")
+	if err := os.WriteFile(input, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	diags := diagnostics.NewList(input, src)
+	tokens, err := tokenizer.Tokenize(src, diags)
+	if err != nil {
+		return native, interpreted, false
+	}
+	prog, err := parser.Parse(tokens, diags)
+	if err != nil {
+		return native, interpreted, false
+	}
+	var stdout strings.Builder
+	interpreted.status, err = interp.Run(prog, diags, &stdout)
+	if err != nil {
+		return native, interpreted, false
+	}
+	interpreted.stdout = stdout.String()
+
+	exePath := filepath.Join(dir, "diff")
+	if err := build(options{input: input, output: exePath, emit: "exe", buildDir: dir}); err != nil {
+		return native, interpreted, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stdout.Reset()
+	cmd := exec.CommandContext(ctx, exePath)
+	cmd.Stdout = &stdout
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		native.status = exitErr.ExitCode()
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			native.status = 128 + int(status.Signal())
+		}
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	native.stdout = stdout.String()
+	return native, interpreted, true
+}
+
+func shrink(t *testing.T, stmts []*genStmt) []*genStmt {
+	for progress := true; progress; {
+		progress = false
+		for _, candidate := range shrinkStmts(stmts) {
+			native, interpreted, ok := compare(t, formatProgram(candidate))
+			if ok && native != interpreted {
+				stmts = candidate
+				progress = true
+				break
+			}
+		}
+	}
+	return stmts
+}
+
+// TestDifferential runs random programs natively and through the interpreter
+// and expects the same exit status and output. A mismatch is shrunk to a small program
+// before it is reported.
+func TestDifferential(t *testing.T) {
+	if _, err := exec.LookPath("nasm"); err != nil {
+		t.Skip("nasm not found")
+	}
+	if _, err := exec.LookPath("ld"); err != nil {
+		t.Skip("ld not found")
+	}
+	count := *diffCount
+	if testing.Short() {
+		count = min(count, 10)
+	}
+
+	for seed := *diffSeed; seed < *diffSeed+int64(count); seed++ {
+		g := &progGen{r: rand.New(rand.NewSource(seed))}
+		prog := g.program()
+		src := formatProgram(prog)
+		native, interpreted, ok := compare(t, src)
+		if !ok {
+			t.Fatalf("seed %d: generated program does not compile:\n%s", seed, src)
+		}
+		if native == interpreted {
+			continue
+		}
+		small := formatProgram(shrink(t, prog))
+		native, interpreted, _ = compare(t, small)
+		t.Fatalf("seed %d: native exit %d with output %q, interpreter exit %d with output %q\n%s",
+			seed, native.status, native.stdout, interpreted.status, interpreted.stdout, small)
+	}
+}
@@ -3,33 +3,78 @@ package generator
 import (
 	"errors"
 	"fmt"
-	"log"
 	"strconv"
 
+	"github.com/arregist97/Hydro-Compiler/diagnostics"
+	"github.com/arregist97/Hydro-Compiler/logger"
 	"github.com/arregist97/Hydro-Compiler/parser"
+	"github.com/arregist97/Hydro-Compiler/tokenizer"
 )
 
 type state struct {
-	stackPtr int
-	context  []map[string]int
-	scopeI   int
-	labelI   int
+	stackPtr   int
+	context    []map[string]int
+	scopeI     int
+	labelI     int
+	loops      []loop
+	inFunction bool
+	functions  string
+	fns        map[string]int
+	calls      []call
+	checked    bool
+	traps      []trap
+	printInt   bool
+	strings    []string
+	diags      *diagnostics.List
 }
 
-func (s *state) enterScope(node *parser.TokenTreeNode, buffer string) (string, error) {
+// Options changes how code is generated.
+type Options struct {
+	// Checked guards division and arithmetic with runtime checks that stop
+	// the program with TrapExitCode instead of faulting or wrapping.
+	Checked bool
+}
+
+type call struct {
+	token *tokenizer.Token
+	nArgs int
+}
+
+var argRegisters = []string{"rdi", "rsi", "rdx", "rcx", "r8", "r9"}
+
+var conditionCodes = map[tokenizer.Kind]string{
+	tokenizer.Eq:        "e",
+	tokenizer.NotEq:     "ne",
+	tokenizer.Less:      "l",
+	tokenizer.LessEq:    "le",
+	tokenizer.Greater:   "g",
+	tokenizer.GreaterEq: "ge",
+}
+
+type loop struct {
+	continueLabel string
+	endLabel      string
+	stackPtr      int
+}
+
+func (s *state) enterScope(scope *parser.Scope, buffer string) (string, error) {
+	s.openScope()
+	for _, stmt := range scope.Stmts {
+		buf, err := evalStmt(stmt, buffer, s)
+		if err != nil {
+			return "", err
+		}
+		buffer = buf
+	}
+	return s.exitScope(buffer)
+}
+
+func (s *state) openScope() {
 	newScope := make(map[string]int)
 	s.scopeI++
 	s.context = append(s.context, newScope)
 	s.decVar("{")
-	fmt.Println("Enter new scope")
-	fmt.Println(s.context)
-
-	buf, err := evalTerminator(node.Left, buffer, s)
-	if err != nil {
-		return "", err
-	}
-	buffer = buf
-	return buffer, nil
+	logger.Tracef(logger.Gen, "Enter new scope %v", s.context)
 }
 
 func (s *state) exitScope(buffer string) (string, error) {
@@ -39,14 +84,24 @@ func (s *state) exitScope(buffer string) (string, error) {
 	}
 	s.context = s.context[:s.scopeI]
 	s.scopeI--
-	fmt.Println("Exit scope")
-	fmt.Println(s.context)
-	stackDiff := s.stackPtr - scopeStkPtr
+	logger.Tracef(logger.Gen, "Exit scope %v", s.context)
+	buffer = s.unwindStack(buffer, scopeStkPtr)
+	s.stackPtr = scopeStkPtr
+	return buffer, nil
+}
+
+func (s *state) unwindStack(buffer string, stkPtr int) string {
+	stackDiff := s.stackPtr - stkPtr
 	if stackDiff > 0 {
 		buffer = buffer + "\n" + "  add    rsp, " + strconv.Itoa(stackDiff*8)
-		s.stackPtr = scopeStkPtr
 	}
-	return buffer, nil
+	return buffer
+}
+
+func (s *state) newLabel() string {
+	label := "label" + strconv.Itoa(s.labelI)
+	s.labelI++
+	return label
 }
 
 func (s *state) decVar(val string) {
@@ -59,8 +114,7 @@ func (s *state) getVar(val string) (int, error) {
 	var stackLoc int
 	var validIdent bool
 
-	fmt.Println("Retrieving var value")
-	fmt.Println(s.context)
+	logger.Tracef(logger.Gen, "Retrieving var %s from %v", val, s.context)
 	for i := s.scopeI; i >= 0; i-- {
 		scope = s.context[i]
 		stackLoc, validIdent = scope[val]
@@ -74,86 +128,100 @@ func (s *state) getVar(val string) (int, error) {
 	return stackLoc, nil
 }
 
-func newState() state {
+func (s *state) report(token *tokenizer.Token, err error) error {
+	s.diags.Report(token.Line, token.Column, err.Error())
+	return err
+}
+
+func newState(diags *diagnostics.List) state {
 	scope := make(map[string]int)
 	context := make([]map[string]int, 1)
 	context[0] = scope
-	s := state{stackPtr: 0, context: context, scopeI: 0, labelI: 0}
-	fmt.Println("State create")
-	fmt.Println(s.context)
+	s := state{stackPtr: 0, context: context, scopeI: 0, labelI: 0, fns: make(map[string]int), diags: diags}
+	logger.Tracef(logger.Gen, "State create %v", s.context)
 	return s
 }
 
-func Generate(node *parser.TokenTreeNode) (string, error) {
+func Generate(prog *parser.Prog, diags *diagnostics.List, opts Options) (string, error) {
 	var buffer string
 	buffer = "global _start"
 	buffer = buffer + "\n" + "_start:"
-	state := newState()
-	buffer, err := evalStmt(node, buffer, &state)
-	return buffer, err
-}
-
-func evalStmt(node *parser.TokenTreeNode, buffer string, state *state) (string, error) {
-	fmt.Println("Evaluating statement " + node.Token.Val + "...")
-	if node.TokenType[0] != "Stmt" {
-		return "", errors.New("statement expected, recieved " + node.TokenType[0])
-	}
-	if node.Token.Val == "EOF" {
-		fmt.Println("Test")
-		buffer = buffer + "\n" + "  mov    rax, 60"
-		buffer = buffer + "\n" + "  mov    rdi, 0"
-		buffer = buffer + "\n" + "  syscall"
-		return buffer, nil
-	}
-	if len(node.TokenType) > 1 && node.TokenType[1] == "StmtTm" {
-		return evalStmt(node.Right, buffer, state)
-	}
-	if node.Token.Val == "exit" {
-		buf, err := evalExit(node.Left, buffer, state)
+	state := newState(diags)
+	state.checked = opts.Checked
+	for _, stmt := range prog.Stmts {
+		buf, err := evalStmt(stmt, buffer, &state)
 		if err != nil {
-			return "", err
+			if diags.Len() == 0 {
+				state.report(stmt.Pos(), err)
+			}
+			return "", diags.Err()
 		}
 		buffer = buf
+	}
+	buffer = buffer + "\n" + "  mov    rax, 60"
+	buffer = buffer + "\n" + "  mov    rdi, 0"
+	buffer = buffer + "\n" + "  syscall"
 
-	} else if node.Token.Val == "let" {
-		buf, err := evalLet(node.Right, buffer, state)
-		if err != nil {
-			return "", err
+	for _, c := range state.calls {
+		nParams, ok := state.fns[c.token.Val]
+		if !ok {
+			state.report(c.token, errors.New("undeclared function "+c.token.Val))
+		} else if nParams != c.nArgs {
+			state.report(c.token, fmt.Errorf("function %s expects %d arguments, recieved %d", c.token.Val, nParams, c.nArgs))
 		}
-		buffer = buf
+	}
+	if diags.Len() > 0 {
+		return "", diags.Err()
+	}
+	buffer = buffer + state.functions + state.runtime()
+	return buffer, nil
+}
 
-		node = node.Right.Right
-	} else if node.Token.Val == "if" {
-		buf, nd, err := evalIf(node, buffer, state)
-		if err != nil {
-			return "", err
-		}
-		buffer = buf
-		node = nd
-		fmt.Println("Exiting if, node: " + node.Token.Val)
-	} else if node.Token.Val == "{" {
-		buf, err := state.enterScope(node, buffer)
-		if err != nil {
-			return "", err
-		}
-		buffer = buf
-	} else if node.Token.Val == "}" {
-		buffer, err := state.exitScope(buffer)
+func evalStmt(stmt parser.Stmt, buffer string, state *state) (string, error) {
+	logger.Tracef(logger.Gen, "Evaluating statement %s...", stmt.Pos().Val)
+	switch s := stmt.(type) {
+	case *parser.ExitStmt:
+		return evalExit(s, buffer, state)
+	case *parser.PrintStmt:
+		return evalPrint(s, buffer, state)
+	case *parser.LetStmt:
+		return evalLet(s, buffer, state)
+	case *parser.AssignStmt:
+		return evalAssign(s, buffer, state)
+	case *parser.OpAssignStmt:
+		return evalOpAssign(s, buffer, state)
+	case *parser.IncDecStmt:
+		return evalIncDec(s, buffer, state)
+	case *parser.IfStmt:
+		return evalIf(s, buffer, state)
+	case *parser.WhileStmt:
+		return evalWhile(s, buffer, state)
+	case *parser.ForStmt:
+		return evalFor(s, buffer, state)
+	case *parser.BreakStmt:
+		return evalLoopJump(s.Token, buffer, state)
+	case *parser.ContinueStmt:
+		return evalLoopJump(s.Token, buffer, state)
+	case *parser.FnStmt:
+		return buffer, evalFn(s, state)
+	case *parser.ReturnStmt:
+		return evalReturn(s, buffer, state)
+	case *parser.ExprStmt:
+		buf, err := evalExpr(s.Expr, buffer, state)
 		if err != nil {
 			return "", err
 		}
+		buffer = buf + "\n" + "  add    rsp, 8"
+		state.stackPtr--
 		return buffer, nil
-	} else {
-		return "", errors.New("undefined Stmt: " + node.Token.Val)
+	case *parser.Scope:
+		return state.enterScope(s, buffer)
 	}
-	return evalTerminator(node.Right, buffer, state)
+	return "", state.report(stmt.Pos(), errors.New("undefined Stmt: "+stmt.Pos().Val))
 }
 
-func evalExit(node *parser.TokenTreeNode, buffer string, state *state) (string, error) {
-	if node.Token.Val != "(" {
-		return "", errors.New("expected `(` after exit")
-	}
-	buffer, err := evalExpr(node, buffer, state, false)
+func evalExit(stmt *parser.ExitStmt, buffer string, state *state) (string, error) {
+	buffer, err := evalExpr(stmt.Expr, buffer, state)
 	if err != nil {
 		return "", err
 	}
@@ -164,199 +232,530 @@ func evalExit(node *parser.TokenTreeNode, buffer string, state *state) (string,
 	return buffer, nil
 }
 
-func evalLet(node *parser.TokenTreeNode, buffer string, state *state) (string, error) {
-	if len(node.TokenType) > 2 && node.TokenType[2] != "ident" {
-		log.Fatal("Improper declaration")
+func evalPrint(stmt *parser.PrintStmt, buffer string, state *state) (string, error) {
+	if lit, ok := stmt.Expr.(*parser.StrLit); ok {
+		text := lit.Value + "\n"
+		buffer = buffer + "\n" + "  mov    rax, 1"
+		buffer = buffer + "\n" + "  mov    rdi, 1"
+		buffer = buffer + "\n" + "  lea    rsi, [rel " + state.stringLabel(text) + "]"
+		buffer = buffer + "\n" + "  mov    rdx, " + strconv.Itoa(len(text))
+		buffer = buffer + "\n" + "  syscall"
+		return buffer, nil
 	}
-	if node.Right.Token.Val != "=" {
-		log.Fatal("Expected '='")
+	buffer, err := evalExpr(stmt.Expr, buffer, state)
+	if err != nil {
+		return "", err
 	}
-	buf, err := evalExpr(node.Right.Left, buffer, state, false)
+	buffer = buffer + "\n" + "  pop    rdi"
+	buffer = buffer + "\n" + "  call   rt_print_int"
+	state.stackPtr--
+	state.printInt = true
+	return buffer, nil
+}
+
+func evalLet(stmt *parser.LetStmt, buffer string, state *state) (string, error) {
+	buffer, err := evalExpr(stmt.Expr, buffer, state)
 	if err != nil {
 		return "", err
 	}
-	buffer = buf
 
-	state.decVar(node.Token.Val)
+	state.decVar(stmt.Ident.Token.Val)
 	return buffer, nil
 }
 
-func evalIf(node *parser.TokenTreeNode, buffer string, state *state) (string, *parser.TokenTreeNode, error) {
-	buffer, err := evalExpr(node.Left, buffer, state, false)
+func evalAssign(stmt *parser.AssignStmt, buffer string, state *state) (string, error) {
+	buffer, err := evalExpr(stmt.Expr, buffer, state)
 	if err != nil {
-		return "", nil, err
+		return "", err
+	}
+
+	stackLoc, err := state.getVar(stmt.Ident.Token.Val)
+	if err != nil {
+		return "", state.report(stmt.Ident.Token, err)
+	}
+	buffer = buffer + "\n" + "  pop    rax"
+	state.stackPtr--
+	stackOffset := (state.stackPtr - stackLoc) * 8
+	buffer = buffer + "\n" + "  mov    QWORD [rsp + " + strconv.Itoa(stackOffset) + "], rax"
+	return buffer, nil
+}
+
+// evalOpAssign applies the operator directly to the variable's stack slot,
+// with the operand in rbx as in evalBinExpr.
+func evalOpAssign(stmt *parser.OpAssignStmt, buffer string, state *state) (string, error) {
+	buffer, err := evalExpr(stmt.Expr, buffer, state)
+	if err != nil {
+		return "", err
+	}
+
+	stackLoc, err := state.getVar(stmt.Ident.Token.Val)
+	if err != nil {
+		return "", state.report(stmt.Ident.Token, err)
+	}
+	buffer = buffer + "\n" + "  pop    rbx"
+	state.stackPtr--
+	slot := "QWORD [rsp + " + strconv.Itoa((state.stackPtr-stackLoc)*8) + "]"
+
+	op, _ := tokenizer.AssignOp(stmt.Op.Kind)
+	switch op {
+	case tokenizer.Plus:
+		buffer = buffer + "\n" + "  add    " + slot + ", rbx"
+		if state.checked {
+			buffer = state.guard(buffer, "jno", state.newLabel(), overflowTrap, stmt.Op)
+		}
+	case tokenizer.Minus:
+		buffer = buffer + "\n" + "  sub    " + slot + ", rbx"
+		if state.checked {
+			buffer = state.guard(buffer, "jno", state.newLabel(), overflowTrap, stmt.Op)
+		}
+	case tokenizer.Star:
+		buffer = buffer + "\n" + "  mov    rax, " + slot
+		buffer = buffer + "\n" + "  imul   rax, rbx"
+		if state.checked {
+			buffer = state.guard(buffer, "jno", state.newLabel(), overflowTrap, stmt.Op)
+		}
+		buffer = buffer + "\n" + "  mov    " + slot + ", rax"
+	case tokenizer.Slash, tokenizer.Percent:
+		buffer = buffer + "\n" + "  mov    rax, " + slot
+		buffer = evalDivGuard(stmt.Op, buffer, state)
+		buffer = buffer + "\n" + "  cqo"
+		buffer = buffer + "\n" + "  idiv   rbx"
+		if op == tokenizer.Percent {
+			buffer = buffer + "\n" + "  mov    rax, rdx"
+		}
+		buffer = buffer + "\n" + "  mov    " + slot + ", rax"
+	default:
+		return "", state.report(stmt.Op, errors.New("invalid assignment: "+stmt.Op.Val))
+	}
+	return buffer, nil
+}
+
+func evalIncDec(stmt *parser.IncDecStmt, buffer string, state *state) (string, error) {
+	stackLoc, err := state.getVar(stmt.Ident.Token.Val)
+	if err != nil {
+		return "", state.report(stmt.Ident.Token, err)
+	}
+	slot := "QWORD [rsp + " + strconv.Itoa((state.stackPtr-stackLoc)*8) + "]"
+	if stmt.Op.Kind == tokenizer.Inc {
+		buffer = buffer + "\n" + "  inc    " + slot
+	} else {
+		buffer = buffer + "\n" + "  dec    " + slot
+	}
+	if state.checked {
+		buffer = state.guard(buffer, "jno", state.newLabel(), overflowTrap, stmt.Op)
+	}
+	return buffer, nil
+}
+
+func evalCond(cond parser.Expr, label string, buffer string, state *state) (string, error) {
+	buffer, err := evalExpr(cond, buffer, state)
+	if err != nil {
+		return "", err
 	}
-	label := "label" + strconv.Itoa(state.labelI)
-	state.labelI++
 	buffer = buffer + "\n" + "  pop    rax"
 	buffer = buffer + "\n" + "  test   rax, rax"
 	buffer = buffer + "\n" + "  jz     " + label
 	state.stackPtr--
+	return buffer, nil
+}
 
-	node = node.Right
-	if node.Token.Val != "{" {
-		return "", nil, errors.New("expected scope")
+func evalIf(stmt *parser.IfStmt, buffer string, state *state) (string, error) {
+	label := state.newLabel()
+	buffer, err := evalCond(stmt.Cond, label, buffer, state)
+	if err != nil {
+		return "", err
 	}
-	buffer, err = state.enterScope(node, buffer)
+	buffer, err = state.enterScope(stmt.Scope, buffer)
 	if err != nil {
-		return "", nil, err
+		return "", err
 	}
 
-	next := node.Right
-	if next.TokenType[0] != "ifPred" {
+	if len(stmt.Elifs) == 0 && stmt.Else == nil {
 		buffer = buffer + "\n" + label + ":"
-		return buffer, node, nil
+		return buffer, nil
 	}
 
-	endLabel := "label" + strconv.Itoa(state.labelI)
-	state.labelI++
-	for next.Token.Val == "elif" {
-		node = next
-		next = node.Right
+	endLabel := state.newLabel()
+	for _, elif := range stmt.Elifs {
+		buffer = buffer + "\n" + "  jmp    " + endLabel
+		buffer = buffer + "\n" + label + ":"
+
+		label = state.newLabel()
+		buffer, err = evalCond(elif.Cond, label, buffer, state)
+		if err != nil {
+			return "", err
+		}
+		buffer, err = state.enterScope(elif.Scope, buffer)
+		if err != nil {
+			return "", err
+		}
+	}
 
+	if stmt.Else != nil {
 		buffer = buffer + "\n" + "  jmp    " + endLabel
 		buffer = buffer + "\n" + label + ":"
 
-		buffer, err = evalExpr(node.Left, buffer, state, false)
+		buffer, err = state.enterScope(stmt.Else, buffer)
 		if err != nil {
-			return "", nil, err
+			return "", err
 		}
+	} else {
+		buffer = buffer + "\n" + label + ":"
+	}
 
-		label = "label" + strconv.Itoa(state.labelI)
-		state.labelI++
-		buffer = buffer + "\n" + "  pop    rax"
-		buffer = buffer + "\n" + "  test   rax, rax"
-		buffer = buffer + "\n" + "  jz     " + label
-		state.stackPtr--
+	buffer = buffer + "\n" + endLabel + ":"
+
+	return buffer, nil
+}
+
+func evalWhile(stmt *parser.WhileStmt, buffer string, state *state) (string, error) {
+	startLabel := state.newLabel()
+	endLabel := state.newLabel()
+	buffer = buffer + "\n" + startLabel + ":"
+
+	buffer, err := evalCond(stmt.Cond, endLabel, buffer, state)
+	if err != nil {
+		return "", err
+	}
 
-		if next.Token.Val != "{" {
-			return "", nil, errors.New("expected scope")
+	state.loops = append(state.loops, loop{continueLabel: startLabel, endLabel: endLabel, stackPtr: state.stackPtr})
+	buffer, err = state.enterScope(stmt.Scope, buffer)
+	if err != nil {
+		return "", err
+	}
+	state.loops = state.loops[:len(state.loops)-1]
+
+	buffer = buffer + "\n" + "  jmp    " + startLabel
+	buffer = buffer + "\n" + endLabel + ":"
+
+	return buffer, nil
+}
+
+// evalFor declares the initializer in a scope of its own around the loop.
+// continue jumps to the step, which falls through to the condition.
+func evalFor(stmt *parser.ForStmt, buffer string, state *state) (string, error) {
+	state.openScope()
+	var err error
+	if stmt.Init != nil {
+		buffer, err = evalStmt(stmt.Init, buffer, state)
+		if err != nil {
+			return "", err
 		}
-		node = next
-		next = node.Right
+	}
 
-		buffer, err = state.enterScope(node, buffer)
+	startLabel := state.newLabel()
+	stepLabel := state.newLabel()
+	endLabel := state.newLabel()
+	buffer = buffer + "\n" + startLabel + ":"
+	if stmt.Cond != nil {
+		buffer, err = evalCond(stmt.Cond, endLabel, buffer, state)
 		if err != nil {
-			return "", nil, err
+			return "", err
 		}
 	}
 
-	if next.Token.Val == "else" {
-		node = next
-		next = node.Right
+	state.loops = append(state.loops, loop{continueLabel: stepLabel, endLabel: endLabel, stackPtr: state.stackPtr})
+	buffer, err = state.enterScope(stmt.Scope, buffer)
+	if err != nil {
+		return "", err
+	}
+	state.loops = state.loops[:len(state.loops)-1]
 
-		buffer = buffer + "\n" + "  jmp    " + endLabel
-		buffer = buffer + "\n" + label + ":"
+	buffer = buffer + "\n" + stepLabel + ":"
+	if stmt.Step != nil {
+		buffer, err = evalStmt(stmt.Step, buffer, state)
+		if err != nil {
+			return "", err
+		}
+	}
+	buffer = buffer + "\n" + "  jmp    " + startLabel
+	buffer = buffer + "\n" + endLabel + ":"
+	return state.exitScope(buffer)
+}
 
-		if next.Token.Val != "{" {
-			return "", nil, errors.New("expected scope")
+func evalLoopJump(token *tokenizer.Token, buffer string, state *state) (string, error) {
+	if len(state.loops) <= 0 {
+		return "", state.report(token, errors.New(token.Val+" outside of loop"))
+	}
+	l := state.loops[len(state.loops)-1]
+	buffer = state.unwindStack(buffer, l.stackPtr)
+	if token.Kind == tokenizer.Break {
+		buffer = buffer + "\n" + "  jmp    " + l.endLabel
+	} else {
+		buffer = buffer + "\n" + "  jmp    " + l.continueLabel
+	}
+	return buffer, nil
+}
+
+func evalFn(stmt *parser.FnStmt, state *state) error {
+	name := stmt.Name.Token.Val
+	if _, ok := state.fns[name]; ok {
+		return state.report(stmt.Name.Token, errors.New("function "+name+" already declared"))
+	}
+	state.fns[name] = len(stmt.Params)
+
+	outer := *state
+	state.stackPtr = 0
+	state.context = []map[string]int{make(map[string]int)}
+	state.scopeI = 0
+	state.loops = nil
+	state.inFunction = true
+
+	buffer := "\n" + "fn_" + name + ":"
+	buffer = buffer + "\n" + "  push   rbp"
+	buffer = buffer + "\n" + "  mov    rbp, rsp"
+	for i, param := range stmt.Params {
+		if _, ok := state.context[0][param.Token.Val]; ok {
+			return state.report(param.Token, errors.New("duplicate parameter "+param.Token.Val))
+		}
+		if i < len(argRegisters) {
+			buffer = buffer + "\n" + "  push   " + argRegisters[i]
+		} else {
+			stackOffset := 16 + (i-len(argRegisters))*8
+			buffer = buffer + "\n" + "  push   QWORD [rbp + " + strconv.Itoa(stackOffset) + "]"
 		}
-		node = next
-		buffer, err = state.enterScope(node, buffer)
+		state.stackPtr++
+		state.decVar(param.Token.Val)
+	}
+
+	buffer, err := state.enterScope(stmt.Scope, buffer)
+	if err != nil {
+		return err
+	}
+	buffer = buffer + "\n" + "  mov    rax, 0"
+	buffer = buffer + "\n" + "  mov    rsp, rbp"
+	buffer = buffer + "\n" + "  pop    rbp"
+	buffer = buffer + "\n" + "  ret"
+	state.functions = state.functions + buffer
+
+	state.stackPtr = outer.stackPtr
+	state.context = outer.context
+	state.scopeI = outer.scopeI
+	state.loops = outer.loops
+	state.inFunction = outer.inFunction
+	return nil
+}
+
+func evalReturn(stmt *parser.ReturnStmt, buffer string, state *state) (string, error) {
+	if !state.inFunction {
+		return "", state.report(stmt.Token, errors.New("return outside of function"))
+	}
+	if stmt.Expr != nil {
+		buf, err := evalExpr(stmt.Expr, buffer, state)
 		if err != nil {
-			return "", nil, err
+			return "", err
 		}
+		buffer = buf + "\n" + "  pop    rax"
+		state.stackPtr--
 	} else {
-		buffer = buffer + "\n" + label + ":"
+		buffer = buffer + "\n" + "  mov    rax, 0"
 	}
+	buffer = buffer + "\n" + "  mov    rsp, rbp"
+	buffer = buffer + "\n" + "  pop    rbp"
+	buffer = buffer + "\n" + "  ret"
+	return buffer, nil
+}
 
-	buffer = buffer + "\n" + endLabel + ":"
+func evalExpr(expr parser.Expr, buffer string, state *state) (string, error) {
+	switch e := expr.(type) {
+	case *parser.IntLit:
+		buffer = buffer + "\n" + "  mov    rax, " + strconv.FormatUint(e.Token.Int, 10)
+		buffer = buffer + "\n" + "  push   rax"
+		state.stackPtr++
+		return buffer, nil
+	case *parser.Ident:
+		return evalIdent(e, buffer, state)
+	case *parser.BinaryExpr:
+		return evalBinExpr(e, buffer, state)
+	case *parser.UnaryExpr:
+		return evalUnaryExpr(e, buffer, state)
+	case *parser.CallExpr:
+		return evalCall(e, buffer, state)
+	}
+	return "", state.report(expr.Pos(), errors.New("invalid expression: "+expr.Pos().Val))
+}
+
+func evalIdent(ident *parser.Ident, buffer string, state *state) (string, error) {
+	stackLoc, err := state.getVar(ident.Token.Val)
+	if err != nil {
+		return "", state.report(ident.Token, err)
+	}
 
-	return buffer, node, nil
+	stackOffset := (state.stackPtr - stackLoc) * 8
+	logger.Tracef(logger.Gen, "Stack pointer %d, var location %d, offset %d", state.stackPtr, stackLoc, stackOffset)
+	buffer = buffer + "\n" + "  push   QWORD [rsp + " + strconv.Itoa(stackOffset) + "]"
+	state.stackPtr++
+	return buffer, nil
 }
 
-func evalExpr(node *parser.TokenTreeNode, buffer string, state *state, paren bool) (string, error) {
-	if node.TokenType[0] != "Expr" {
-		fmt.Println("Node val: " + node.Token.Val)
-		return "", errors.New("expression expected, recieved " + node.TokenType[0])
+func evalUnaryExpr(expr *parser.UnaryExpr, buffer string, state *state) (string, error) {
+	buffer, err := evalExpr(expr.Operand, buffer, state)
+	if err != nil {
+		return "", err
 	}
-	if node.Token.Val == "(" {
-		return evalExpr(node.Left, buffer, state, true)
+	buffer = buffer + "\n" + "  pop    rax"
+	if expr.Op.Kind == tokenizer.Not {
+		buffer = buffer + "\n" + "  test   rax, rax"
+		buffer = buffer + "\n" + "  sete   al"
+		buffer = buffer + "\n" + "  movzx  rax, al"
+	} else if expr.Op.Kind == tokenizer.Minus {
+		buffer = buffer + "\n" + "  neg    rax"
+		if state.checked {
+			buffer = state.guard(buffer, "jno", state.newLabel(), overflowTrap, expr.Op)
+		}
+	} else if expr.Op.Kind == tokenizer.BitNot {
+		buffer = buffer + "\n" + "  not    rax"
+	} else {
+		return "", state.report(expr.Op, errors.New("invalid unary expression: "+expr.Op.Val))
 	}
-	if node.TokenType[1] == "Term" {
-		return evalTerm(node, buffer, state, paren)
+	buffer = buffer + "\n" + "  push   rax"
+	return buffer, nil
+}
+
+// evalLogicalExpr short-circuits `&&` and `||`. Both paths reach the end label
+// with the flags of the last `test`, so `setne` yields the 0/1 result.
+func evalLogicalExpr(expr *parser.BinaryExpr, buffer string, state *state) (string, error) {
+	jump := "  jz     "
+	if expr.Op.Kind == tokenizer.Or {
+		jump = "  jnz    "
 	}
-	if node.TokenType[1] == "StkVr" {
-		return buffer, nil
+	endLabel := state.newLabel()
+
+	buffer, err := evalExpr(expr.Left, buffer, state)
+	if err != nil {
+		return "", err
 	}
-	if node.TokenType[1] == "ExprOp" {
-		return evalBinExpr(node, buffer, state, paren)
+	buffer = buffer + "\n" + "  pop    rax"
+	buffer = buffer + "\n" + "  test   rax, rax"
+	buffer = buffer + "\n" + jump + endLabel
+	state.stackPtr--
+
+	buffer, err = evalExpr(expr.Right, buffer, state)
+	if err != nil {
+		return "", err
 	}
-	return "", errors.New("invalid expression: " + node.TokenType[1])
+	buffer = buffer + "\n" + "  pop    rax"
+	buffer = buffer + "\n" + "  test   rax, rax"
+	buffer = buffer + "\n" + endLabel + ":"
+	buffer = buffer + "\n" + "  setne  al"
+	buffer = buffer + "\n" + "  movzx  rax, al"
+	buffer = buffer + "\n" + "  push   rax"
+	return buffer, nil
 }
 
-func evalBinExpr(node *parser.TokenTreeNode, buffer string, state *state, paren bool) (string, error) {
+func evalBinExpr(expr *parser.BinaryExpr, buffer string, state *state) (string, error) {
+	if expr.Op.Kind == tokenizer.And || expr.Op.Kind == tokenizer.Or {
+		return evalLogicalExpr(expr, buffer, state)
+	}
 	var err error
-	buffer, err = evalExpr(node.Left, buffer, state, false)
+	buffer, err = evalExpr(expr.Left, buffer, state)
 	if err != nil {
 		return "", err
 	}
-	buffer, err = evalExpr(node.Right, buffer, state, paren)
+	buffer, err = evalExpr(expr.Right, buffer, state)
 	if err != nil {
 		return "", err
 	}
-	buffer = buffer + "\n" + "  pop    rax"
 	buffer = buffer + "\n" + "  pop    rbx"
-	if node.Token.Val == "+" {
+	buffer = buffer + "\n" + "  pop    rax"
+	if expr.Op.Kind == tokenizer.Plus {
 		buffer = buffer + "\n" + "  add    rax, rbx"
-		buffer = buffer + "\n" + "  push   rax"
-	} else if node.Token.Val == "*" {
-		buffer = buffer + "\n" + "  mul    rbx"
-		buffer = buffer + "\n" + "  push   rax"
-	} else if node.Token.Val == "-" {
-		buffer = buffer + "\n" + "  sub    rbx, rax"
-		buffer = buffer + "\n" + "  push   rbx"
-	} else if node.Token.Val == "/" {
-		buffer = buffer + "\n" + "  div    rbx"
-		buffer = buffer + "\n" + "  push   rax"
+		if state.checked {
+			buffer = state.guard(buffer, "jno", state.newLabel(), overflowTrap, expr.Op)
+		}
+	} else if expr.Op.Kind == tokenizer.Star {
+		buffer = buffer + "\n" + "  imul   rax, rbx"
+		if state.checked {
+			buffer = state.guard(buffer, "jno", state.newLabel(), overflowTrap, expr.Op)
+		}
+	} else if expr.Op.Kind == tokenizer.Minus {
+		buffer = buffer + "\n" + "  sub    rax, rbx"
+		if state.checked {
+			buffer = state.guard(buffer, "jno", state.newLabel(), overflowTrap, expr.Op)
+		}
+	} else if expr.Op.Kind == tokenizer.Slash {
+		buffer = evalDivGuard(expr.Op, buffer, state)
+		buffer = buffer + "\n" + "  cqo"
+		buffer = buffer + "\n" + "  idiv   rbx"
+	} else if expr.Op.Kind == tokenizer.Percent {
+		buffer = evalDivGuard(expr.Op, buffer, state)
+		buffer = buffer + "\n" + "  cqo"
+		buffer = buffer + "\n" + "  idiv   rbx"
+		buffer = buffer + "\n" + "  mov    rax, rdx"
+	} else if expr.Op.Kind == tokenizer.BitAnd {
+		buffer = buffer + "\n" + "  and    rax, rbx"
+	} else if expr.Op.Kind == tokenizer.BitOr {
+		buffer = buffer + "\n" + "  or     rax, rbx"
+	} else if expr.Op.Kind == tokenizer.BitXor {
+		buffer = buffer + "\n" + "  xor    rax, rbx"
+	} else if expr.Op.Kind == tokenizer.Shl || expr.Op.Kind == tokenizer.Shr {
+		// The count must be in cl, and only its low six bits are used.
+		buffer = buffer + "\n" + "  mov    rcx, rbx"
+		if expr.Op.Kind == tokenizer.Shl {
+			buffer = buffer + "\n" + "  sal    rax, cl"
+		} else {
+			buffer = buffer + "\n" + "  sar    rax, cl"
+		}
+	} else if cc, ok := conditionCodes[expr.Op.Kind]; ok {
+		buffer = buffer + "\n" + "  cmp    rax, rbx"
+		buffer = buffer + "\n" + fmt.Sprintf("  %-7s", "set"+cc) + "al"
+		buffer = buffer + "\n" + "  movzx  rax, al"
 	} else {
-		return "", errors.New("invalid binary expression: " + node.Token.Val)
+		return "", state.report(expr.Op, errors.New("invalid binary expression: "+expr.Op.Val))
 	}
+	buffer = buffer + "\n" + "  push   rax"
 	state.stackPtr--
 
 	return buffer, nil
 }
 
-func evalTerm(node *parser.TokenTreeNode, buffer string, state *state, paren bool) (string, error) {
-	if node.TokenType[2] == "intLit" {
-		buffer = buffer + "\n" + "  mov    rax, " + node.Token.Val
-		buffer = buffer + "\n" + "  push   rax"
-		state.stackPtr++
-	} else if node.TokenType[2] == "ident" {
-		stackLoc, err := state.getVar(node.Token.Val)
-		if err != nil {
-			return "", err
-		}
-
-		fmt.Println("Stack Pointer", state.stackPtr, "var location", stackLoc)
-		stackOffset := (state.stackPtr - stackLoc) * 8
-		fmt.Println(stackOffset)
-		buffer = buffer + "\n" + "  push   QWORD [rsp + " + strconv.Itoa(stackOffset) + "]"
-		state.stackPtr++
-	} else {
-		return "", errors.New("invalid term: " + node.TokenType[2])
-	}
-	if paren && (node.Right == nil || node.Right.Token.Val != ")") {
-		return "", errors.New("expected ')'")
-	}
-	return buffer, nil
+// evalDivGuard checks the divisor in rbx before an idiv in checked mode. idiv
+// faults on a zero divisor and on the minimum value divided by -1, whose
+// quotient does not fit; the latter is caught by negating rax.
+func evalDivGuard(op *tokenizer.Token, buffer string, state *state) string {
+	if !state.checked {
+		return buffer
+	}
+	buffer = buffer + "\n" + "  test   rbx, rbx"
+	buffer = state.guard(buffer, "jnz", state.newLabel(), divZeroTrap, op)
+	okLabel := state.newLabel()
+	buffer = buffer + "\n" + "  cmp    rbx, -1"
+	buffer = buffer + "\n" + "  jne    " + okLabel
+	buffer = buffer + "\n" + "  mov    rcx, rax"
+	buffer = buffer + "\n" + "  neg    rcx"
+	return state.guard(buffer, "jno", okLabel, overflowTrap, op)
 }
 
-func evalTerminator(node *parser.TokenTreeNode, buffer string, state *state) (string, error) {
-	fmt.Println("Evaluating terminator: " + node.Token.Val)
-	if len(node.TokenType) > 1 && node.TokenType[1] == "StmtTm" {
-		if node.Token.Val == "EOF" {
-			return evalStmt(node, buffer, state)
-		}
-		return evalStmt(node.Right, buffer, state)
+// evalCall follows the System V convention. Arguments are pushed right to left
+// so that after popping the first six into registers the rest are already in
+// place, and rsp is kept 16 byte aligned at the call.
+func evalCall(expr *parser.CallExpr, buffer string, state *state) (string, error) {
+	name := expr.Name.Token.Val
+	state.calls = append(state.calls, call{token: expr.Name.Token, nArgs: len(expr.Args)})
+
+	nStackArgs := max(len(expr.Args)-len(argRegisters), 0)
+	padding := (state.stackPtr + nStackArgs) % 2
+	if padding > 0 {
+		buffer = buffer + "\n" + "  sub    rsp, 8"
+		state.stackPtr++
 	}
-	if node.Token.Val == "}" {
-		buf, err := state.exitScope(buffer)
+	for i := len(expr.Args) - 1; i >= 0; i-- {
+		buf, err := evalExpr(expr.Args[i], buffer, state)
 		if err != nil {
 			return "", err
 		}
 		buffer = buf
-		return evalTerminator(node.Right, buffer, state)
 	}
-	return "", errors.New("invalid terminator: " + node.Token.Val)
+	for i := 0; i < len(expr.Args) && i < len(argRegisters); i++ {
+		buffer = buffer + "\n" + "  pop    " + argRegisters[i]
+		state.stackPtr--
+	}
+	buffer = buffer + "\n" + "  call   fn_" + name
+	if nStackArgs+padding > 0 {
+		buffer = buffer + "\n" + "  add    rsp, " + strconv.Itoa((nStackArgs+padding)*8)
+		state.stackPtr = state.stackPtr - nStackArgs - padding
+	}
+	buffer = buffer + "\n" + "  push   rax"
+	state.stackPtr++
+	return buffer, nil
 }
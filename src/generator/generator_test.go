@@ -0,0 +1,235 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/arregist97/Hydro-Compiler/diagnostics"
+	"github.com/arregist97/Hydro-Compiler/parser"
+	"github.com/arregist97/Hydro-Compiler/tokenizer"
+)
+
+func generate(src string) (string, *diagnostics.List, error) {
+	return generateWith(src, Options{})
+}
+
+func generateWith(src string, opts Options) (string, *diagnostics.List, error) {
+	diags := diagnostics.NewList("test.hy", src)
+	tokens, err := tokenizer.Tokenize(src, diags)
+	if err != nil {
+		return "", diags, err
+	}
+	prog, err := parser.Parse(tokens, diags)
+	if err != nil {
+		return "", diags, err
+	}
+	asm, err := Generate(prog, diags, opts)
+	return asm, diags, err
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"exit", "exit(7)", []string{
+			"global _start\n_start:",
+			"  mov    rax, 7\n  push   rax\n  mov    rax, 60\n  pop    rdi\n  syscall",
+		}},
+		{"literals", "exit('a' + 0x1F + 0b1_0 + 0xFFFF_FFFF_FFFF_FFFF)", []string{
+			"  mov    rax, 97\n",
+			"  mov    rax, 31\n",
+			"  mov    rax, 2\n",
+			"  mov    rax, 18446744073709551615\n",
+		}},
+		{"variable offsets", "let x = 1\nlet y = 2\nexit(x)", []string{
+			"  push   QWORD [rsp + 8]",
+		}},
+		{"assign", "let x = 1\nlet y = 2\nx = 3", []string{
+			"  pop    rax\n  mov    QWORD [rsp + 8], rax",
+		}},
+		{"compound assignment", "let x = 1\nlet y = 2\nx += 3\nx *= y\nx %= 5\ny++\nx--", []string{
+			"  mov    rax, 3\n  push   rax\n  pop    rbx\n  add    QWORD [rsp + 8], rbx",
+			"  push   QWORD [rsp + 0]\n  pop    rbx\n  mov    rax, QWORD [rsp + 8]\n  imul   rax, rbx\n  mov    QWORD [rsp + 8], rax",
+			"  cqo\n  idiv   rbx\n  mov    rax, rdx\n  mov    QWORD [rsp + 8], rax",
+			"  inc    QWORD [rsp + 0]",
+			"  dec    QWORD [rsp + 8]",
+		}},
+		{"scope unwinds", "{\nlet x = 1\nlet y = 2\n}", []string{
+			"  add    rsp, 16",
+		}},
+		{"signed arithmetic", "exit(-7 * 2 / 3 % 2)", []string{
+			"  pop    rax\n  neg    rax",
+			"  imul   rax, rbx",
+			"  cqo\n  idiv   rbx\n  push   rax",
+			"  cqo\n  idiv   rbx\n  mov    rax, rdx\n  push   rax",
+		}},
+		{"bitwise", "exit(~1 & 2 | 3 ^ 4)", []string{
+			"  pop    rax\n  not    rax",
+			"  and    rax, rbx",
+			"  or     rax, rbx",
+			"  xor    rax, rbx",
+		}},
+		{"shifts", "exit(1 << 2 >> 3)", []string{
+			"  mov    rcx, rbx\n  sal    rax, cl",
+			"  mov    rcx, rbx\n  sar    rax, cl",
+		}},
+		{"comparison", "exit(1 <= 2)", []string{
+			"  cmp    rax, rbx\n  setle  al\n  movzx  rax, al",
+		}},
+		{"not", "exit(!0)", []string{
+			"  test   rax, rax\n  sete   al",
+		}},
+		{"short circuit", "exit(1 || 0)", []string{
+			"  jnz    label0",
+			"label0:\n  setne  al",
+		}},
+		{"if else", "if 1 {\n} else {\n}", []string{
+			"  jz     label0",
+			"  jmp    label1\nlabel0:",
+			"label1:",
+		}},
+		{"while", "while 1 {\nlet x = 1\nbreak\n}", []string{
+			"label0:",
+			"  jz     label1",
+			"  add    rsp, 8\n  jmp    label1",
+			"  jmp    label0\nlabel1:",
+		}},
+		{"for", "for (let i = 0; i < 3; i++) {\nlet x = i\ncontinue\n}", []string{
+			"  mov    rax, 0\n  push   rax\nlabel0:",
+			"  jz     label2",
+			"  add    rsp, 8\n  jmp    label1",
+			"label1:\n  inc    QWORD [rsp + 0]\n  jmp    label0\nlabel2:\n  add    rsp, 8",
+		}},
+		{"call aligns the stack", "fn f(a) {\nreturn a\n}\nlet x = 1\nexit(f(x))", []string{
+			"fn_f:\n  push   rbp\n  mov    rbp, rsp\n  push   rdi",
+			"  sub    rsp, 8\n  push   QWORD [rsp + 8]\n  pop    rdi\n  call   fn_f\n  add    rsp, 8\n  push   rax",
+			"  mov    rsp, rbp\n  pop    rbp\n  ret",
+		}},
+		{"stack arguments", "fn f(a, b, c, d, e, f, g) {\nreturn g\n}\nexit(f(1, 2, 3, 4, 5, 6, 7))", []string{
+			"  push   QWORD [rbp + 16]",
+			"  call   fn_f\n  add    rsp, 16",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asm, diags, err := generate(tt.src)
+			if err != nil {
+				t.Fatalf("generate(%q) failed:\n%s", tt.src, diags)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(asm, want) {
+					t.Errorf("generate(%q) is missing\n%s\n--- got:\n%s", tt.src, want, asm)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateChecked(t *testing.T) {
+	src := "let x = 1\nlet y = x + 2\nexit(y / x % x)"
+	asm, diags, err := generateWith(src, Options{Checked: true})
+	if err != nil {
+		t.Fatalf("generate failed:\n%s", diags)
+	}
+	for _, want := range []string{
+		"  add    rax, rbx\n  jno    label0\n  mov    rdi, 2\n  jmp    rt_overflow\nlabel0:",
+		"  test   rbx, rbx\n  jnz    label1\n  mov    rdi, 3\n  jmp    rt_div_zero\nlabel1:",
+		"  cmp    rbx, -1\n  jne    label2\n  mov    rcx, rax\n  neg    rcx\n  jno    label2",
+		"rt_trap:",
+		"section .rodata",
+		`rt_div_zero_msg: db ": runtime error: division by zero", 10`,
+		`rt_file: db "test.hy:"`,
+	} {
+		if !strings.Contains(asm, want) {
+			t.Errorf("checked asm is missing\n%s\n--- got:\n%s", want, asm)
+		}
+	}
+	for _, label := range []string{"rt_trap:", "rt_div_zero:", "rt_overflow:"} {
+		if n := strings.Count(asm, "\n"+label); n != 1 {
+			t.Errorf("%s emitted %d times, want once", label, n)
+		}
+	}
+
+	asm, _, _ = generate(src)
+	if strings.Contains(asm, "rt_") {
+		t.Errorf("unchecked asm contains runtime checks:\n%s", asm)
+	}
+}
+
+func TestGeneratePrint(t *testing.T) {
+	asm, diags, err := generateWith("print(1)\nprint(2)\nexit(1 / 0)", Options{Checked: true})
+	if err != nil {
+		t.Fatalf("generate failed:\n%s", diags)
+	}
+	if !strings.Contains(asm, "  mov    rax, 1\n  push   rax\n  pop    rdi\n  call   rt_print_int") {
+		t.Errorf("print does not pass its value in rdi:\n%s", asm)
+	}
+	// The checks and print share the integer formatting routine.
+	for _, label := range []string{"rt_print_int:", "rt_itoa:", "rt_trap:"} {
+		if n := strings.Count(asm, "\n"+label); n != 1 {
+			t.Errorf("%s emitted %d times, want once", label, n)
+		}
+	}
+}
+
+func TestGenerateStrings(t *testing.T) {
+	asm, diags, err := generate("print(\"hi\")\nprint(\"a\\tb\")\nprint(\"hi\")")
+	if err != nil {
+		t.Fatalf("generate failed:\n%s", diags)
+	}
+	for _, want := range []string{
+		"  mov    rax, 1\n  mov    rdi, 1\n  lea    rsi, [rel str0]\n  mov    rdx, 3\n  syscall",
+		"  lea    rsi, [rel str1]\n  mov    rdx, 4",
+		"section .rodata\nstr0: db \"hi\", 10\nstr1: db \"a\", 9, \"b\", 10",
+	} {
+		if !strings.Contains(asm, want) {
+			t.Errorf("asm is missing\n%s\n--- got:\n%s", want, asm)
+		}
+	}
+	// Printing the same text twice reuses its data.
+	if n := strings.Count(asm, "[rel str0]"); n != 2 {
+		t.Errorf("str0 referenced %d times, want 2", n)
+	}
+	if strings.Contains(asm, "str2") {
+		t.Errorf("duplicate string emitted:\n%s", asm)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"exit(x)", []string{"1:6: error: undeclared ident x"}},
+		{"{\nlet x = 1\n}\nx = 2", []string{"4:1: error: undeclared ident x"}},
+		{"break", []string{"1:1: error: break outside of loop"}},
+		{"return 1", []string{"1:1: error: return outside of function"}},
+		{"fn f() {}\nfn f() {}", []string{"2:4: error: function f already declared"}},
+		{"x += 1", []string{"1:1: error: undeclared ident x"}},
+		{"x++", []string{"1:1: error: undeclared ident x"}},
+		{"for (let i = 0; i < 3; i++) {}\nexit(i)", []string{"2:6: error: undeclared ident i"}},
+		{"fn f(a, a) {}", []string{"1:9: error: duplicate parameter a"}},
+		{"fn f() {\nexit(x)\n}", []string{"2:6: error: undeclared ident x"}},
+		{"let x = 1\nfn f() {\nexit(x)\n}", []string{"3:6: error: undeclared ident x"}},
+		{"exit(g(1))\nfn f(a) {}\nf(1, 2)", []string{
+			"1:6: error: undeclared function g",
+			"3:1: error: function f expects 1 arguments, recieved 2",
+		}},
+	}
+	for _, tt := range tests {
+		_, diags, err := generate(tt.src)
+		if err == nil {
+			t.Errorf("generate(%q) succeeded, want %q", tt.src, tt.want)
+			continue
+		}
+		var got []string
+		for _, d := range diags.Diags {
+			got = append(got, strings.TrimPrefix(strings.SplitN(diags.Format(d), "\n", 2)[0], "test.hy:"))
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("generate(%q)\n got: %q\nwant: %q", tt.src, got, tt.want)
+		}
+	}
+}
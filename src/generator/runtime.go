@@ -0,0 +1,197 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/arregist97/Hydro-Compiler/tokenizer"
+)
+
+// TrapExitCode is the exit status of a program stopped by a runtime check.
+const TrapExitCode = 101
+
+type trap struct {
+	label string
+	msg   string
+}
+
+var (
+	divZeroTrap  = trap{label: "rt_div_zero", msg: "division by zero"}
+	overflowTrap = trap{label: "rt_overflow", msg: "integer overflow"}
+)
+
+// guard jumps over a call to the trap when the flags satisfy jump. The
+// caller emits whatever sets the flags and may also jump to okLabel itself.
+func (s *state) guard(buffer string, jump string, okLabel string, t trap, token *tokenizer.Token) string {
+	buffer = buffer + "\n" + fmt.Sprintf("  %-7s", jump) + okLabel
+	buffer = buffer + "\n" + "  mov    rdi, " + strconv.Itoa(token.Line)
+	buffer = buffer + "\n" + "  jmp    " + t.label
+	buffer = buffer + "\n" + okLabel + ":"
+	for _, used := range s.traps {
+		if used == t {
+			return buffer
+		}
+	}
+	s.traps = append(s.traps, t)
+	return buffer
+}
+
+// stringLabel returns the label of a read-only copy of text, reusing the
+// copy when the same text is printed more than once.
+func (s *state) stringLabel(text string) string {
+	for i, str := range s.strings {
+		if str == text {
+			return "str" + strconv.Itoa(i)
+		}
+	}
+	s.strings = append(s.strings, text)
+	return "str" + strconv.Itoa(len(s.strings)-1)
+}
+
+// runtime emits the support routines the program uses, each exactly once,
+// followed by the read-only data they and the program refer to.
+func (s *state) runtime() string {
+	var buffer string
+	var data string
+	if s.printInt {
+		buffer = buffer + printIntRoutine()
+	}
+	if len(s.traps) > 0 {
+		var trapData string
+		buffer, trapData = s.trapRoutines(buffer)
+		data = data + trapData
+	}
+	if s.printInt || len(s.traps) > 0 {
+		buffer = buffer + itoaRoutine()
+	}
+	for i, str := range s.strings {
+		data = data + "\n" + "str" + strconv.Itoa(i) + ": db " + dataBytes(str)
+	}
+	if data != "" {
+		buffer = buffer + "\n" + "section .rodata" + data
+	}
+	return buffer
+}
+
+// itoaRoutine converts the signed integer in rdi to decimal, writing it
+// backwards so that it ends just before rsi, and returns the start in rax.
+// Negating the minimum value leaves it unchanged, which is still the right
+// magnitude when divided as unsigned.
+func itoaRoutine() string {
+	buffer := "\n" + "rt_itoa:"
+	buffer = buffer + "\n" + "  mov    rax, rdi"
+	buffer = buffer + "\n" + "  mov    rcx, 10"
+	buffer = buffer + "\n" + "  test   rax, rax"
+	buffer = buffer + "\n" + "  jns    rt_itoa_digit"
+	buffer = buffer + "\n" + "  neg    rax"
+	buffer = buffer + "\n" + "rt_itoa_digit:"
+	buffer = buffer + "\n" + "  xor    rdx, rdx"
+	buffer = buffer + "\n" + "  div    rcx"
+	buffer = buffer + "\n" + "  add    dl, 48"
+	buffer = buffer + "\n" + "  dec    rsi"
+	buffer = buffer + "\n" + "  mov    BYTE [rsi], dl"
+	buffer = buffer + "\n" + "  test   rax, rax"
+	buffer = buffer + "\n" + "  jnz    rt_itoa_digit"
+	buffer = buffer + "\n" + "  test   rdi, rdi"
+	buffer = buffer + "\n" + "  jns    rt_itoa_done"
+	buffer = buffer + "\n" + "  dec    rsi"
+	buffer = buffer + "\n" + "  mov    BYTE [rsi], 45"
+	buffer = buffer + "\n" + "rt_itoa_done:"
+	buffer = buffer + "\n" + "  mov    rax, rsi"
+	buffer = buffer + "\n" + "  ret"
+	return buffer
+}
+
+// printIntRoutine writes the integer in rdi and a newline to stdout.
+func printIntRoutine() string {
+	buffer := "\n" + "rt_print_int:"
+	buffer = buffer + "\n" + "  push   rbp"
+	buffer = buffer + "\n" + "  mov    rbp, rsp"
+	buffer = buffer + "\n" + "  sub    rsp, 32"
+	buffer = buffer + "\n" + "  lea    rsi, [rbp - 1]"
+	buffer = buffer + "\n" + "  mov    BYTE [rsi], 10"
+	buffer = buffer + "\n" + "  call   rt_itoa"
+	buffer = buffer + "\n" + "  mov    rsi, rax"
+	buffer = buffer + "\n" + "  mov    rdx, rbp"
+	buffer = buffer + "\n" + "  sub    rdx, rsi"
+	buffer = buffer + "\n" + "  mov    rax, 1"
+	buffer = buffer + "\n" + "  mov    rdi, 1"
+	buffer = buffer + "\n" + "  syscall"
+	buffer = buffer + "\n" + "  mov    rsp, rbp"
+	buffer = buffer + "\n" + "  pop    rbp"
+	buffer = buffer + "\n" + "  ret"
+	return buffer
+}
+
+// trapRoutines emits an entry point per trap, each loading its message and
+// falling into rt_trap, which prints `file:line: runtime error: msg` to stderr,
+// with the line passed in rdi, and exits with TrapExitCode.
+func (s *state) trapRoutines(buffer string) (string, string) {
+	var data string
+	for _, t := range s.traps {
+		msg := ": runtime error: " + t.msg + "\n"
+		buffer = buffer + "\n" + t.label + ":"
+		buffer = buffer + "\n" + "  lea    rsi, [rel " + t.label + "_msg]"
+		buffer = buffer + "\n" + "  mov    rdx, " + strconv.Itoa(len(msg))
+		buffer = buffer + "\n" + "  jmp    rt_trap"
+		data = data + "\n" + t.label + "_msg: db " + dataBytes(msg)
+	}
+	file := s.diags.File + ":"
+	data = data + "\n" + "rt_file: db " + dataBytes(file)
+
+	buffer = buffer + "\n" + "rt_trap:"
+	buffer = buffer + "\n" + "  mov    r12, rdi"
+	buffer = buffer + "\n" + "  mov    r13, rsi"
+	buffer = buffer + "\n" + "  mov    r14, rdx"
+	buffer = buffer + "\n" + "  mov    rax, 1"
+	buffer = buffer + "\n" + "  mov    rdi, 2"
+	buffer = buffer + "\n" + "  lea    rsi, [rel rt_file]"
+	buffer = buffer + "\n" + "  mov    rdx, " + strconv.Itoa(len(file))
+	buffer = buffer + "\n" + "  syscall"
+	buffer = buffer + "\n" + "  sub    rsp, 32"
+	buffer = buffer + "\n" + "  mov    rdi, r12"
+	buffer = buffer + "\n" + "  lea    rsi, [rsp + 32]"
+	buffer = buffer + "\n" + "  call   rt_itoa"
+	buffer = buffer + "\n" + "  mov    rsi, rax"
+	buffer = buffer + "\n" + "  lea    rdx, [rsp + 32]"
+	buffer = buffer + "\n" + "  sub    rdx, rsi"
+	buffer = buffer + "\n" + "  mov    rax, 1"
+	buffer = buffer + "\n" + "  mov    rdi, 2"
+	buffer = buffer + "\n" + "  syscall"
+	buffer = buffer + "\n" + "  mov    rax, 1"
+	buffer = buffer + "\n" + "  mov    rdi, 2"
+	buffer = buffer + "\n" + "  mov    rsi, r13"
+	buffer = buffer + "\n" + "  mov    rdx, r14"
+	buffer = buffer + "\n" + "  syscall"
+	buffer = buffer + "\n" + "  mov    rax, 60"
+	buffer = buffer + "\n" + "  mov    rdi, " + strconv.Itoa(TrapExitCode)
+	buffer = buffer + "\n" + "  syscall"
+	return buffer, data
+}
+
+// dataBytes renders s as the operand list of a `db` directive. Printable
+// characters are quoted and everything else is written as a number.
+func dataBytes(s string) string {
+	var parts []string
+	var run strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= ' ' && c <= '~' && c != '"' {
+			run.WriteByte(c)
+			continue
+		}
+		if run.Len() > 0 {
+			parts = append(parts, `"`+run.String()+`"`)
+			run.Reset()
+		}
+		parts = append(parts, strconv.Itoa(int(c)))
+	}
+	if run.Len() > 0 {
+		parts = append(parts, `"`+run.String()+`"`)
+	}
+	if len(parts) == 0 {
+		return `""`
+	}
+	return strings.Join(parts, ", ")
+}
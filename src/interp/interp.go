@@ -0,0 +1,519 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/arregist97/Hydro-Compiler/diagnostics"
+	"github.com/arregist97/Hydro-Compiler/parser"
+	"github.com/arregist97/Hydro-Compiler/tokenizer"
+)
+
+// maxCallDepth bounds recursion so that a runaway program is reported instead
+// of overflowing the interpreter's own stack.
+const maxCallDepth = 10000
+
+// state mirrors generator.state: context holds one map per open scope, lets
+// declare into the innermost one and lookups walk outwards. Values live in the
+// maps instead of stack slots.
+type state struct {
+	context    []map[string]int64
+	scopeI     int
+	loops      int
+	inFunction bool
+	calls      int
+	fns        map[string]*parser.FnStmt
+	ret        int64
+	stdout     io.Writer
+	diags      *diagnostics.List
+}
+
+// flow tells the enclosing statement how control left a statement.
+type flow int
+
+const (
+	next flow = iota
+	breakLoop
+	continueLoop
+	returnFn
+)
+
+// exitStatus unwinds the interpreter from an exit statement, which may be
+// reached from inside any expression through a call.
+type exitStatus struct {
+	code int64
+}
+
+func (e *exitStatus) Error() string {
+	return fmt.Sprintf("exit(%d)", e.code)
+}
+
+func (s *state) enterScope(scope *parser.Scope) (flow, error) {
+	s.context = append(s.context, make(map[string]int64))
+	s.scopeI++
+
+	f, err := evalStmts(scope.Stmts, s)
+	s.exitScope()
+	return f, err
+}
+
+func (s *state) exitScope() {
+	s.context = s.context[:s.scopeI]
+	s.scopeI--
+}
+
+func (s *state) decVar(name string, val int64) {
+	s.context[s.scopeI][name] = val
+}
+
+func (s *state) lookup(name string) (map[string]int64, error) {
+	for i := s.scopeI; i >= 0; i-- {
+		if _, ok := s.context[i][name]; ok {
+			return s.context[i], nil
+		}
+	}
+	return nil, errors.New("undeclared ident " + name)
+}
+
+func (s *state) report(token *tokenizer.Token, err error) error {
+	s.diags.Report(token.Line, token.Column, err.Error())
+	return err
+}
+
+func newState(diags *diagnostics.List, stdout io.Writer) *state {
+	return &state{
+		context: []map[string]int64{make(map[string]int64)},
+		fns:     make(map[string]*parser.FnStmt),
+		stdout:  stdout,
+		diags:   diags,
+	}
+}
+
+// Run executes prog, writing what it prints to stdout, and returns its exit
+// code, truncated to a byte the same way the exit syscall does.
+func Run(prog *parser.Prog, diags *diagnostics.List, stdout io.Writer) (int, error) {
+	state := newState(diags, stdout)
+	declareFns(prog.Stmts, state)
+	if diags.Len() > 0 {
+		return 0, diags.Err()
+	}
+
+	_, err := evalStmts(prog.Stmts, state)
+	var exit *exitStatus
+	if errors.As(err, &exit) {
+		return int(uint8(exit.code)), nil
+	}
+	if err != nil && diags.Len() > 0 {
+		return 0, diags.Err()
+	}
+	return 0, err
+}
+
+// declareFns registers every function up front. The generator declares
+// functions while walking the tree and checks calls at the end, so a call may
+// come before the definition and a function nested in a scope is still global.
+func declareFns(stmts []parser.Stmt, state *state) {
+	for _, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *parser.FnStmt:
+			name := s.Name.Token.Val
+			if _, ok := state.fns[name]; ok {
+				state.report(s.Name.Token, errors.New("function "+name+" already declared"))
+				continue
+			}
+			state.fns[name] = s
+			seen := make(map[string]bool)
+			for _, param := range s.Params {
+				if seen[param.Token.Val] {
+					state.report(param.Token, errors.New("duplicate parameter "+param.Token.Val))
+				}
+				seen[param.Token.Val] = true
+			}
+			declareFns(s.Scope.Stmts, state)
+		case *parser.Scope:
+			declareFns(s.Stmts, state)
+		case *parser.IfStmt:
+			declareFns(s.Scope.Stmts, state)
+			for _, elif := range s.Elifs {
+				declareFns(elif.Scope.Stmts, state)
+			}
+			if s.Else != nil {
+				declareFns(s.Else.Stmts, state)
+			}
+		case *parser.WhileStmt:
+			declareFns(s.Scope.Stmts, state)
+		case *parser.ForStmt:
+			declareFns(s.Scope.Stmts, state)
+		}
+	}
+}
+
+func evalStmts(stmts []parser.Stmt, state *state) (flow, error) {
+	for _, stmt := range stmts {
+		f, err := evalStmt(stmt, state)
+		if err != nil || f != next {
+			return f, err
+		}
+	}
+	return next, nil
+}
+
+func evalStmt(stmt parser.Stmt, state *state) (flow, error) {
+	switch s := stmt.(type) {
+	case *parser.ExitStmt:
+		val, err := evalExpr(s.Expr, state)
+		if err != nil {
+			return next, err
+		}
+		return next, &exitStatus{code: val}
+	case *parser.PrintStmt:
+		if lit, ok := s.Expr.(*parser.StrLit); ok {
+			fmt.Fprintln(state.stdout, lit.Value)
+			return next, nil
+		}
+		val, err := evalExpr(s.Expr, state)
+		if err != nil {
+			return next, err
+		}
+		fmt.Fprintln(state.stdout, val)
+		return next, nil
+	case *parser.LetStmt:
+		val, err := evalExpr(s.Expr, state)
+		if err != nil {
+			return next, err
+		}
+		state.decVar(s.Ident.Token.Val, val)
+		return next, nil
+	case *parser.AssignStmt:
+		val, err := evalExpr(s.Expr, state)
+		if err != nil {
+			return next, err
+		}
+		scope, err := state.lookup(s.Ident.Token.Val)
+		if err != nil {
+			return next, state.report(s.Ident.Token, err)
+		}
+		scope[s.Ident.Token.Val] = val
+		return next, nil
+	case *parser.OpAssignStmt:
+		val, err := evalExpr(s.Expr, state)
+		if err != nil {
+			return next, err
+		}
+		scope, err := state.lookup(s.Ident.Token.Val)
+		if err != nil {
+			return next, state.report(s.Ident.Token, err)
+		}
+		op, _ := tokenizer.AssignOp(s.Op.Kind)
+		val, err = applyOp(s.Op, op, scope[s.Ident.Token.Val], val, state)
+		if err != nil {
+			return next, err
+		}
+		scope[s.Ident.Token.Val] = val
+		return next, nil
+	case *parser.IncDecStmt:
+		scope, err := state.lookup(s.Ident.Token.Val)
+		if err != nil {
+			return next, state.report(s.Ident.Token, err)
+		}
+		if s.Op.Kind == tokenizer.Inc {
+			scope[s.Ident.Token.Val]++
+		} else {
+			scope[s.Ident.Token.Val]--
+		}
+		return next, nil
+	case *parser.IfStmt:
+		return evalIf(s, state)
+	case *parser.WhileStmt:
+		return evalWhile(s, state)
+	case *parser.ForStmt:
+		return evalFor(s, state)
+	case *parser.BreakStmt:
+		return evalLoopJump(s.Token, breakLoop, state)
+	case *parser.ContinueStmt:
+		return evalLoopJump(s.Token, continueLoop, state)
+	case *parser.FnStmt:
+		return next, nil
+	case *parser.ReturnStmt:
+		if !state.inFunction {
+			return next, state.report(s.Token, errors.New("return outside of function"))
+		}
+		state.ret = 0
+		if s.Expr != nil {
+			val, err := evalExpr(s.Expr, state)
+			if err != nil {
+				return next, err
+			}
+			state.ret = val
+		}
+		return returnFn, nil
+	case *parser.ExprStmt:
+		_, err := evalExpr(s.Expr, state)
+		return next, err
+	case *parser.Scope:
+		return state.enterScope(s)
+	}
+	return next, state.report(stmt.Pos(), errors.New("undefined Stmt: "+stmt.Pos().Val))
+}
+
+func evalIf(stmt *parser.IfStmt, state *state) (flow, error) {
+	cond, err := evalExpr(stmt.Cond, state)
+	if err != nil {
+		return next, err
+	}
+	if cond != 0 {
+		return state.enterScope(stmt.Scope)
+	}
+	for _, elif := range stmt.Elifs {
+		cond, err := evalExpr(elif.Cond, state)
+		if err != nil {
+			return next, err
+		}
+		if cond != 0 {
+			return state.enterScope(elif.Scope)
+		}
+	}
+	if stmt.Else != nil {
+		return state.enterScope(stmt.Else)
+	}
+	return next, nil
+}
+
+func evalWhile(stmt *parser.WhileStmt, state *state) (flow, error) {
+	state.loops++
+	defer func() { state.loops-- }()
+	for {
+		cond, err := evalExpr(stmt.Cond, state)
+		if err != nil {
+			return next, err
+		}
+		if cond == 0 {
+			return next, nil
+		}
+		f, err := state.enterScope(stmt.Scope)
+		if err != nil {
+			return next, err
+		}
+		if f == breakLoop {
+			return next, nil
+		}
+		if f == returnFn {
+			return f, nil
+		}
+	}
+}
+
+// evalFor keeps the initializer in a scope of its own around the loop and
+// runs the step after the body, including when the body continues.
+func evalFor(stmt *parser.ForStmt, state *state) (flow, error) {
+	state.context = append(state.context, make(map[string]int64))
+	state.scopeI++
+	defer state.exitScope()
+	if stmt.Init != nil {
+		if f, err := evalStmt(stmt.Init, state); err != nil {
+			return f, err
+		}
+	}
+
+	state.loops++
+	defer func() { state.loops-- }()
+	for {
+		if stmt.Cond != nil {
+			cond, err := evalExpr(stmt.Cond, state)
+			if err != nil {
+				return next, err
+			}
+			if cond == 0 {
+				return next, nil
+			}
+		}
+		f, err := state.enterScope(stmt.Scope)
+		if err != nil {
+			return next, err
+		}
+		if f == breakLoop {
+			return next, nil
+		}
+		if f == returnFn {
+			return f, nil
+		}
+		if stmt.Step != nil {
+			if _, err := evalStmt(stmt.Step, state); err != nil {
+				return next, err
+			}
+		}
+	}
+}
+
+func evalLoopJump(token *tokenizer.Token, f flow, state *state) (flow, error) {
+	if state.loops <= 0 {
+		return next, state.report(token, errors.New(token.Val+" outside of loop"))
+	}
+	return f, nil
+}
+
+func evalExpr(expr parser.Expr, state *state) (int64, error) {
+	switch e := expr.(type) {
+	case *parser.IntLit:
+		return int64(e.Token.Int), nil
+	case *parser.Ident:
+		scope, err := state.lookup(e.Token.Val)
+		if err != nil {
+			return 0, state.report(e.Token, err)
+		}
+		return scope[e.Token.Val], nil
+	case *parser.BinaryExpr:
+		return evalBinExpr(e, state)
+	case *parser.UnaryExpr:
+		return evalUnaryExpr(e, state)
+	case *parser.CallExpr:
+		return evalCall(e, state)
+	}
+	return 0, state.report(expr.Pos(), errors.New("invalid expression: "+expr.Pos().Val))
+}
+
+func evalUnaryExpr(expr *parser.UnaryExpr, state *state) (int64, error) {
+	val, err := evalExpr(expr.Operand, state)
+	if err != nil {
+		return 0, err
+	}
+	if expr.Op.Kind == tokenizer.Not {
+		return boolToInt(val == 0), nil
+	}
+	if expr.Op.Kind == tokenizer.Minus {
+		return -val, nil
+	}
+	if expr.Op.Kind == tokenizer.BitNot {
+		return ^val, nil
+	}
+	return 0, state.report(expr.Op, errors.New("invalid unary expression: "+expr.Op.Val))
+}
+
+func evalBinExpr(expr *parser.BinaryExpr, state *state) (int64, error) {
+	left, err := evalExpr(expr.Left, state)
+	if err != nil {
+		return 0, err
+	}
+	if expr.Op.Kind == tokenizer.And && left == 0 {
+		return 0, nil
+	}
+	if expr.Op.Kind == tokenizer.Or && left != 0 {
+		return 1, nil
+	}
+	right, err := evalExpr(expr.Right, state)
+	if err != nil {
+		return 0, err
+	}
+	return applyOp(expr.Op, expr.Op.Kind, left, right, state)
+}
+
+// applyOp evaluates the binary operator kind, which is the operator of token
+// or, for a compound assignment, the operator it applies.
+func applyOp(token *tokenizer.Token, kind tokenizer.Kind, left int64, right int64, state *state) (int64, error) {
+	switch kind {
+	case tokenizer.And, tokenizer.Or:
+		return boolToInt(right != 0), nil
+	case tokenizer.Plus:
+		return left + right, nil
+	case tokenizer.Minus:
+		return left - right, nil
+	case tokenizer.Star:
+		return left * right, nil
+	case tokenizer.Slash, tokenizer.Percent:
+		// idiv faults on both of these, so the program would die with SIGFPE.
+		if right == 0 {
+			return 0, state.report(token, errors.New("division by zero"))
+		}
+		if left == math.MinInt64 && right == -1 {
+			return 0, state.report(token, errors.New("division overflow"))
+		}
+		if kind == tokenizer.Slash {
+			return left / right, nil
+		}
+		return left % right, nil
+	case tokenizer.BitAnd:
+		return left & right, nil
+	case tokenizer.BitOr:
+		return left | right, nil
+	case tokenizer.BitXor:
+		return left ^ right, nil
+	case tokenizer.Shl:
+		// sal and sar mask the count to six bits.
+		return left << (right & 63), nil
+	case tokenizer.Shr:
+		return left >> (right & 63), nil
+	case tokenizer.Eq:
+		return boolToInt(left == right), nil
+	case tokenizer.NotEq:
+		return boolToInt(left != right), nil
+	case tokenizer.Less:
+		return boolToInt(left < right), nil
+	case tokenizer.LessEq:
+		return boolToInt(left <= right), nil
+	case tokenizer.Greater:
+		return boolToInt(left > right), nil
+	case tokenizer.GreaterEq:
+		return boolToInt(left >= right), nil
+	}
+	return 0, state.report(token, errors.New("invalid binary expression: "+token.Val))
+}
+
+// evalCall evaluates arguments right to left like the generated code, then runs
+// the body in a fresh context that only holds the parameters.
+func evalCall(expr *parser.CallExpr, state *state) (int64, error) {
+	name := expr.Name.Token.Val
+	fn, ok := state.fns[name]
+	if !ok {
+		return 0, state.report(expr.Name.Token, errors.New("undeclared function "+name))
+	}
+	if len(fn.Params) != len(expr.Args) {
+		return 0, state.report(expr.Name.Token, fmt.Errorf("function %s expects %d arguments, recieved %d", name, len(fn.Params), len(expr.Args)))
+	}
+
+	args := make([]int64, len(expr.Args))
+	for i := len(expr.Args) - 1; i >= 0; i-- {
+		val, err := evalExpr(expr.Args[i], state)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = val
+	}
+
+	if state.calls >= maxCallDepth {
+		return 0, state.report(expr.Name.Token, fmt.Errorf("call depth exceeded %d calling %s", maxCallDepth, name))
+	}
+	state.calls++
+	defer func() { state.calls-- }()
+
+	outer := *state
+	state.context = []map[string]int64{make(map[string]int64)}
+	state.scopeI = 0
+	state.loops = 0
+	state.inFunction = true
+	for i, param := range fn.Params {
+		state.decVar(param.Token.Val, args[i])
+	}
+
+	f, err := state.enterScope(fn.Scope)
+	ret := state.ret
+	state.context = outer.context
+	state.scopeI = outer.scopeI
+	state.loops = outer.loops
+	state.inFunction = outer.inFunction
+	if err != nil {
+		return 0, err
+	}
+	if f != returnFn {
+		ret = 0
+	}
+	return ret, nil
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,75 @@
+package interp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/arregist97/Hydro-Compiler/diagnostics"
+	"github.com/arregist97/Hydro-Compiler/parser"
+	"github.com/arregist97/Hydro-Compiler/tokenizer"
+)
+
+func run(src string) (int, string, *diagnostics.List, error) {
+	diags := diagnostics.NewList("test.hy", src)
+	tokens, err := tokenizer.Tokenize(src, diags)
+	if err != nil {
+		return 0, "", diags, err
+	}
+	prog, err := parser.Parse(tokens, diags)
+	if err != nil {
+		return 0, "", diags, err
+	}
+	var stdout strings.Builder
+	code, err := Run(prog, diags, &stdout)
+	return code, stdout.String(), diags, err
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		want   int
+		stdout string
+	}{
+		{"exit", "exit(300)", 44, ""},
+		{"print", "print(-5)\nprint(\"hi\")", 0, "-5\nhi\n"},
+		{"deep recursion", "fn f(n) {\nif n == 0 {\nreturn 0\n}\nreturn f(n - 1) + 1\n}\nexit(f(5000) % 256)", 5000 % 256, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, diags, err := run(tt.src)
+			if err != nil {
+				t.Fatalf("run(%q) failed:\n%s", tt.src, diags)
+			}
+			if code != tt.want || stdout != tt.stdout {
+				t.Errorf("run(%q) = %d, %q, want %d, %q", tt.src, code, stdout, tt.want, tt.stdout)
+			}
+		})
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"exit(1 / 0)", "1:8: error: division by zero"},
+		{"let x = -9223372036854775807 - 1\nexit(x % -1)", "2:8: error: division overflow"},
+		{"fn f(n) {\nreturn f(n + 1)\n}\nexit(f(0))", "2:8: error: call depth exceeded 10000 calling f"},
+	}
+	for _, tt := range tests {
+		_, _, diags, err := run(tt.src)
+		if err == nil {
+			t.Errorf("run(%q) succeeded, want %q", tt.src, tt.want)
+			continue
+		}
+		if diags.Len() == 0 {
+			t.Errorf("run(%q) failed without a diagnostic: %v", tt.src, err)
+			continue
+		}
+		got := strings.TrimPrefix(strings.SplitN(diags.Format(diags.Diags[0]), "\n", 2)[0], "test.hy:")
+		if got != tt.want {
+			t.Errorf("run(%q)\n got: %q\nwant: %q", tt.src, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type Phase int
+
+const (
+	Tokens Phase = 1 << iota
+	Parse
+	Gen
+)
+
+const AllPhases = Tokens | Parse | Gen
+
+var phaseNames = map[string]Phase{
+	"tokens": Tokens,
+	"parse":  Parse,
+	"gen":    Gen,
+	"all":    AllPhases,
+}
+
+var (
+	out     io.Writer = os.Stderr
+	verbose bool
+	phases  Phase
+)
+
+func SetOutput(w io.Writer) {
+	out = w
+}
+
+func SetVerbose(v bool) {
+	verbose = v
+}
+
+func EnablePhases(p Phase) {
+	phases = phases | p
+}
+
+func Enabled(p Phase) bool {
+	return phases&p != 0
+}
+
+// ParsePhases turns a comma separated list such as "tokens,gen" into a Phase set.
+func ParsePhases(spec string) (Phase, error) {
+	var p Phase
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		phase, ok := phaseNames[name]
+		if !ok {
+			return 0, errors.New("unknown trace phase " + name + ", expected tokens, parse, gen or all")
+		}
+		p = p | phase
+	}
+	return p, nil
+}
+
+func Infof(format string, a ...any) {
+	if verbose {
+		fmt.Fprintf(out, format+"\n", a...)
+	}
+}
+
+func Tracef(p Phase, format string, a ...any) {
+	if Enabled(p) {
+		fmt.Fprintf(out, format+"\n", a...)
+	}
+}
+
+// Writer returns the trace output for phase p, or io.Discard when p is off.
+func Writer(p Phase) io.Writer {
+	if Enabled(p) {
+		return out
+	}
+	return io.Discard
+}
@@ -1,94 +1,356 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
+	"strings"
+	"syscall"
 
+	"github.com/arregist97/Hydro-Compiler/diagnostics"
 	"github.com/arregist97/Hydro-Compiler/generator"
+	"github.com/arregist97/Hydro-Compiler/interp"
+	"github.com/arregist97/Hydro-Compiler/logger"
 	"github.com/arregist97/Hydro-Compiler/parser"
 	"github.com/arregist97/Hydro-Compiler/tokenizer"
 )
 
+// Each --emit kind is a stop point in the pipeline, listed in the order the
+// compiler reaches them.
+var emitKinds = []string{"tokens", "ast", "asm", "obj", "exe"}
+
+type options struct {
+	input    string
+	output   string
+	emit     string
+	buildDir string
+	checked  bool
+}
+
 func main() {
-	if len(os.Args) != 2 {
-		fmt.Println("Incorrect Usage. Expected:")
-		fmt.Println("main.go <filename>")
-		return
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "run" {
+		os.Exit(runCommand(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "interpret" {
+		os.Exit(interpretCommand(args[1:]))
+	}
+	os.Exit(compileCommand(args))
+}
+
+type logFlags struct {
+	verbose *bool
+	trace   *string
+}
+
+func addLogFlags(fs *flag.FlagSet) *logFlags {
+	return &logFlags{
+		verbose: fs.Bool("v", false, "print each build step"),
+		trace:   fs.String("trace", "", "comma separated phases to trace to stderr: tokens, parse, gen or all"),
+	}
+}
+
+func (f *logFlags) apply() {
+	logger.SetVerbose(*f.verbose)
+	phases, err := logger.ParsePhases(*f.trace)
+	if err != nil {
+		usageError(err.Error())
+	}
+	logger.EnablePhases(phases)
+}
+
+func compileCommand(args []string) int {
+	fs := flag.NewFlagSet("Hydro-Compiler", flag.ExitOnError)
+	logs := addLogFlags(fs)
+	output := fs.String("o", "", "write the final output to `path`")
+	emit := fs.String("emit", "exe", "stop after producing `kind`: tokens, ast, asm, obj or exe")
+	buildDir := fs.String("build-dir", "../build", "`dir` for the .asm and .o files")
+	asmOnly := fs.Bool("S", false, "stop after writing assembly, same as --emit=asm")
+	objOnly := fs.Bool("c", false, "stop after assembling, same as --emit=obj")
+	checked := fs.Bool("checked", false, "stop with a runtime error on division by zero and overflow")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: Hydro-Compiler [flags] <filename>")
+		fmt.Fprintln(fs.Output(), "       Hydro-Compiler run [flags] <filename>")
+		fmt.Fprintln(fs.Output(), "       Hydro-Compiler interpret [flags] <filename>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	logs.apply()
+
+	if *asmOnly && *objOnly {
+		usageError("-S and -c cannot be used together")
 	}
+	if *asmOnly {
+		*emit = "asm"
+	}
+	if *objOnly {
+		*emit = "obj"
+	}
+	if stage(*emit) < 0 {
+		usageError("unknown emit kind " + *emit + ", expected " + strings.Join(emitKinds, ", "))
+	}
+
+	err := build(options{
+		input:    fs.Arg(0),
+		output:   *output,
+		emit:     *emit,
+		buildDir: *buildDir,
+		checked:  *checked,
+	})
+	if err != nil {
+		reportError(err)
+		return 1
+	}
+	return 0
+}
 
-	fileName := os.Args[1]
+// runCommand builds the program in a private temporary directory, runs it
+// and returns its exit status. A program killed by a signal reports
+// 128+signal, the same as a shell would.
+func runCommand(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	logs := addLogFlags(fs)
+	checked := fs.Bool("checked", false, "stop with a runtime error on division by zero and overflow")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: Hydro-Compiler run [flags] <filename>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	logs.apply()
 
-	content, err := os.ReadFile(fileName)
+	dir, err := os.MkdirTemp("", "hydro-run-")
 	if err != nil {
-		fmt.Println("Error reading file:", err)
-		return
+		reportError(err)
+		return 1
 	}
+	defer os.RemoveAll(dir)
 
-	var tokens []*tokenizer.Token
-	tokens = tokenizer.Tokenize(string(content), tokens)
-	store := parser.NewNodeStore()
-	tree := parser.BuildTokenTree(store, tokens, false)
-	fmt.Println("\nToken Tree:")
-	tree.PrintTokenTree()
+	input := fs.Arg(0)
+	exePath := filepath.Join(dir, strings.TrimSuffix(filepath.Base(input), filepath.Ext(input)))
+	err = build(options{
+		input:    input,
+		output:   exePath,
+		emit:     "exe",
+		buildDir: dir,
+		checked:  *checked,
+	})
+	if err != nil {
+		reportError(err)
+		return 1
+	}
 
-	buffer, err := generator.Generate(tree)
+	logger.Infof("running %s", exePath)
+	cmd := exec.Command(exePath)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+		return exitErr.ExitCode()
+	}
 	if err != nil {
-		log.Fatal(err)
+		reportError(err)
+		return 1
 	}
-	fmt.Println(buffer)
+	return 0
+}
 
-	fileName = filepath.Base(fileName)
-	re := regexp.MustCompile(`\.[^.]+$`)
-	baseName := re.ReplaceAllString(fileName, "")
-	directory := "../build/"
-	newFileName := baseName + ".asm"
-	buildPath := directory + newFileName
+// interpretCommand executes the program with the interp package instead of
+// building it, and returns the exit code the program would have had.
+func interpretCommand(args []string) int {
+	fs := flag.NewFlagSet("interpret", flag.ExitOnError)
+	logs := addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: Hydro-Compiler interpret [flags] <filename>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	logs.apply()
 
-	newFile, err := os.Create(buildPath)
+	input := fs.Arg(0)
+	content, err := os.ReadFile(input)
+	if err != nil {
+		reportError(err)
+		return 1
+	}
+	diags := diagnostics.NewList(input, string(content))
+	tokens, err := tokenizer.Tokenize(string(content), diags)
+	if err != nil {
+		reportError(err)
+		return 1
+	}
+	prog, err := parser.Parse(tokens, diags)
 	if err != nil {
-		fmt.Println("failed to create new file: ", err)
+		reportError(err)
+		return 1
+	}
+	if logger.Enabled(logger.Parse) {
+		logger.Tracef(logger.Parse, "Syntax Tree:")
+		parser.Fprint(logger.Writer(logger.Parse), prog)
 	}
-	defer newFile.Close()
 
-	_, err = newFile.WriteString(buffer)
+	code, err := interp.Run(prog, diags, os.Stdout)
 	if err != nil {
-		fmt.Println("failed to write to new file: ", err)
+		reportError(err)
+		return 1
 	}
+	return code
+}
 
-	oFileName := baseName + ".o"
-	fmt.Println("nasm -felf64", newFileName)
-	nasmCmd := exec.Command("nasm", "-felf64", newFileName)
+func reportError(err error) {
+	var diags *diagnostics.List
+	if errors.As(err, &diags) {
+		diags.Print(os.Stderr)
+	} else {
+		fmt.Fprintln(os.Stderr, "error:", err)
+	}
+}
 
-	nasmCmd.Dir = "../build"
+func usageError(msg string) {
+	fmt.Fprintln(os.Stderr, msg)
+	os.Exit(2)
+}
 
-	nasmCmd.Stdout = os.Stdout
-	nasmCmd.Stderr = os.Stderr
+func stage(emit string) int {
+	for i, kind := range emitKinds {
+		if kind == emit {
+			return i
+		}
+	}
+	return -1
+}
 
-	// Run the nasm command
-	err = nasmCmd.Run()
+// build runs the pipeline up to opts.emit. Tokens and the AST go to stdout
+// unless -o is given; every later stage writes files.
+func build(opts options) error {
+	content, err := os.ReadFile(opts.input)
 	if err != nil {
-		log.Fatalf("nasm command execution failed: %v", err)
+		return err
 	}
 
-	// Step 2: Run ld command
-	fmt.Println("Running ld test.o -o test")
+	diags := diagnostics.NewList(opts.input, string(content))
+	tokens, err := tokenizer.Tokenize(string(content), diags)
+	if err != nil {
+		return err
+	}
+	if opts.emit == "tokens" {
+		var sb strings.Builder
+		for _, token := range tokens {
+			fmt.Fprintln(&sb, token)
+		}
+		return writeOutput(opts.output, sb.String())
+	}
 
-	// Create the ld command
-	ldCmd := exec.Command("ld", oFileName, "-o", baseName)
-	ldCmd.Dir = "../build"
-	ldCmd.Stdout = os.Stdout
-	ldCmd.Stderr = os.Stderr
+	prog, err := parser.Parse(tokens, diags)
+	if err != nil {
+		return err
+	}
+	if logger.Enabled(logger.Parse) {
+		logger.Tracef(logger.Parse, "Syntax Tree:")
+		parser.Fprint(logger.Writer(logger.Parse), prog)
+	}
+	if opts.emit == "ast" {
+		var sb strings.Builder
+		parser.Fprint(&sb, prog)
+		return writeOutput(opts.output, sb.String())
+	}
 
-	// Run the ld command
-	err = ldCmd.Run()
+	buffer, err := generator.Generate(prog, diags, generator.Options{Checked: opts.checked})
 	if err != nil {
-		log.Fatalf("ld command execution failed: %v", err)
+		return err
+	}
+	logger.Tracef(logger.Gen, "%s", buffer)
+
+	if err := os.MkdirAll(opts.buildDir, 0o755); err != nil {
+		return err
+	}
+	baseName := strings.TrimSuffix(filepath.Base(opts.input), filepath.Ext(opts.input))
+
+	asmPath := filepath.Join(opts.buildDir, baseName+".asm")
+	if opts.emit == "asm" && opts.output != "" {
+		asmPath = opts.output
+	}
+	if err := writeFile(asmPath, buffer); err != nil {
+		return err
+	}
+	if opts.emit == "asm" {
+		return nil
+	}
+
+	objPath := filepath.Join(opts.buildDir, baseName+".o")
+	if opts.emit == "obj" && opts.output != "" {
+		objPath = opts.output
+	}
+	if err := runTool("nasm", "-felf64", asmPath, "-o", objPath); err != nil {
+		return err
+	}
+	if opts.emit == "obj" {
+		return nil
 	}
 
-	fmt.Println("Successfully assembled and linked the program.")
+	exePath := filepath.Join(opts.buildDir, baseName)
+	if opts.output != "" {
+		exePath = opts.output
+	}
+	if err := runTool("ld", objPath, "-o", exePath); err != nil {
+		return err
+	}
 
+	logger.Infof("Successfully assembled and linked the program.")
+	return nil
+}
+
+// writeOutput writes to path, or to stdout when no path was given.
+func writeOutput(path string, content string) error {
+	if path == "" {
+		_, err := os.Stdout.WriteString(content)
+		return err
+	}
+	return writeFile(path, content)
+}
+
+func writeFile(path string, content string) error {
+	logger.Infof("writing %s", path)
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func runTool(name string, args ...string) error {
+	logger.Infof("%s %s", name, strings.Join(args, " "))
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", name, err)
+	}
+	return nil
 }
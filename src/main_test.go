@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/arregist97/Hydro-Compiler/diagnostics"
+	"github.com/arregist97/Hydro-Compiler/generator"
+	"github.com/arregist97/Hydro-Compiler/interp"
+	"github.com/arregist97/Hydro-Compiler/parser"
+	"github.com/arregist97/Hydro-Compiler/tokenizer"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testing/golden")
+
+// Every program under testing/ starts with a `// expect: N` comment giving
+// its exit code, optionally followed by `// stdout: line` comments giving
+// what it prints.
+var (
+	expectHeader = regexp.MustCompile(`^// expect: (\d+)\r?\n`)
+	stdoutHeader = regexp.MustCompile(`^// stdout: (.*?)\r?\n`)
+)
+
+func testPrograms(t *testing.T) []string {
+	files, err := filepath.Glob("../testing/*.hy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no programs found in ../testing")
+	}
+	return files
+}
+
+func expectedResult(t *testing.T, src []byte) (int, string) {
+	m := expectHeader.FindSubmatch(src)
+	if m == nil {
+		t.Fatal("missing `// expect: N` header")
+	}
+	code, err := strconv.Atoi(string(m[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout string
+	rest := src[len(m[0]):]
+	for m = stdoutHeader.FindSubmatch(rest); m != nil; m = stdoutHeader.FindSubmatch(rest) {
+		stdout = stdout + string(m[1]) + "\n"
+		rest = rest[len(m[0]):]
+	}
+	return code, stdout
+}
+
+// TestPrograms compiles each program to assembly and checks the exit code and
+// output with the interpreter. When nasm and ld are installed it also links
+// the program and checks the native executable the same way.
+func TestPrograms(t *testing.T) {
+	_, nasmErr := exec.LookPath("nasm")
+	_, ldErr := exec.LookPath("ld")
+	native := nasmErr == nil && ldErr == nil
+
+	for _, file := range testPrograms(t) {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, wantStdout := expectedResult(t, src)
+
+			diags := diagnostics.NewList(file, string(src))
+			tokens, err := tokenizer.Tokenize(string(src), diags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			prog, err := parser.Parse(tokens, diags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var stdout strings.Builder
+			got, err := interp.Run(prog, diags, &stdout)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("interpreted exit code %d, want %d", got, want)
+			}
+			if stdout.String() != wantStdout {
+				t.Errorf("interpreted output %q, want %q", stdout.String(), wantStdout)
+			}
+
+			dir := t.TempDir()
+			emit := "asm"
+			if native {
+				emit = "exe"
+			}
+			exePath := filepath.Join(dir, "prog")
+			err = build(options{input: file, output: exePath, emit: emit, buildDir: dir})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !native {
+				t.Skip("nasm or ld not found, skipping the native run")
+			}
+
+			stdout.Reset()
+			cmd := exec.Command(exePath)
+			cmd.Stdout = &stdout
+			err = cmd.Run()
+			got = 0
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				got = exitErr.ExitCode()
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("native exit code %d, want %d", got, want)
+			}
+			if stdout.String() != wantStdout {
+				t.Errorf("native output %q, want %q", stdout.String(), wantStdout)
+			}
+		})
+	}
+}
+
+// TestChecked builds programs with --checked and expects each to stop with a
+// runtime error naming the offending line.
+func TestChecked(t *testing.T) {
+	_, nasmErr := exec.LookPath("nasm")
+	_, ldErr := exec.LookPath("ld")
+	if nasmErr != nil || ldErr != nil {
+		t.Skip("nasm or ld not found")
+	}
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"division by zero", "let x = 0\nexit(10 / x)", "checked.hy:2: runtime error: division by zero\n"},
+		{"modulo by zero", "let x = 0\nexit(10 % x)", "checked.hy:2: runtime error: division by zero\n"},
+		{"add", "let x = 9223372036854775807\nexit(x + 1)", "checked.hy:2: runtime error: integer overflow\n"},
+		{"sub", "let x = -9223372036854775807\nexit(x - 2)", "checked.hy:2: runtime error: integer overflow\n"},
+		{"mul", "let x = 4294967296\nexit(x * x)", "checked.hy:2: runtime error: integer overflow\n"},
+		{"neg", "let x = -9223372036854775807 - 1\nexit(-x)", "checked.hy:2: runtime error: integer overflow\n"},
+		{"div", "let x = -9223372036854775807 - 1\nexit(x / -1)", "checked.hy:2: runtime error: integer overflow\n"},
+		{"compound add", "let x = 9223372036854775807\nx += 1", "checked.hy:2: runtime error: integer overflow\n"},
+		{"compound div", "let x = 0\nlet y = 1\ny /= x", "checked.hy:3: runtime error: division by zero\n"},
+		{"dec", "let x = -9223372036854775807 - 1\nx--", "checked.hy:2: runtime error: integer overflow\n"},
+		{"line number", strings.Repeat("\n", 122) + "exit(1 / 0)", "checked.hy:123: runtime error: division by zero\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			input := filepath.Join(dir, "checked.hy")
+			if err := os.WriteFile(input, []byte(tt.src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			exePath := filepath.Join(dir, "checked")
+			err := build(options{input: input, output: exePath, emit: "exe", buildDir: dir, checked: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var stderr strings.Builder
+			cmd := exec.Command(exePath)
+			cmd.Stderr = &stderr
+			err = cmd.Run()
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) || exitErr.ExitCode() != generator.TrapExitCode {
+				t.Errorf("exit status %v, want %d", err, generator.TrapExitCode)
+			}
+			if got := strings.TrimPrefix(stderr.String(), dir+"/"); got != tt.want {
+				t.Errorf("stderr %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGolden compares the token stream, AST dump and assembly of each program
+// with the snapshots in testing/golden. Run `go test -run TestGolden -update`
+// to regenerate them after an intended change.
+func TestGolden(t *testing.T) {
+	for _, file := range testPrograms(t) {
+		name := strings.TrimSuffix(filepath.Base(file), ".hy")
+		for _, emit := range []string{"tokens", "ast", "asm"} {
+			t.Run(name+"."+emit, func(t *testing.T) {
+				dir := t.TempDir()
+				out := filepath.Join(dir, "out")
+				err := build(options{input: file, output: out, emit: emit, buildDir: dir})
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := os.ReadFile(out)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				golden := filepath.Join("../testing/golden", name+"."+emit)
+				if *update {
+					if err := os.WriteFile(golden, got, 0o644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("%v (run go test -update to create it)", err)
+				}
+				if string(got) != string(want) {
+					t.Errorf("output differs from %s (run go test -update if the change is intended)\n%s", golden, diffLines(string(want), string(got)))
+				}
+			})
+		}
+	}
+}
+
+// diffLines reports the first line where want and got differ.
+func diffLines(want string, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n-%s\n+%s", i+1, w, g)
+		}
+	}
+	return ""
+}
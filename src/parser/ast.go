@@ -0,0 +1,208 @@
+package parser
+
+import "github.com/arregist97/Hydro-Compiler/tokenizer"
+
+type Node interface {
+	Pos() *tokenizer.Token
+}
+
+type Stmt interface {
+	Node
+	stmtNode()
+}
+
+type Expr interface {
+	Node
+	exprNode()
+}
+
+type Prog struct {
+	Stmts []Stmt
+	EOF   *tokenizer.Token
+}
+
+type Scope struct {
+	Token *tokenizer.Token
+	Stmts []Stmt
+	End   *tokenizer.Token
+}
+
+type ExitStmt struct {
+	Token *tokenizer.Token
+	Expr  Expr
+}
+
+type PrintStmt struct {
+	Token *tokenizer.Token
+	Expr  Expr
+}
+
+type LetStmt struct {
+	Token *tokenizer.Token
+	Ident *Ident
+	Expr  Expr
+}
+
+type AssignStmt struct {
+	Ident *Ident
+	Expr  Expr
+}
+
+// OpAssignStmt is a compound assignment such as `x += 1`.
+type OpAssignStmt struct {
+	Ident *Ident
+	Op    *tokenizer.Token
+	Expr  Expr
+}
+
+// IncDecStmt is `x++` or `x--`.
+type IncDecStmt struct {
+	Ident *Ident
+	Op    *tokenizer.Token
+}
+
+type IfStmt struct {
+	Token *tokenizer.Token
+	Cond  Expr
+	Scope *Scope
+	Elifs []*ElifClause
+	Else  *Scope
+}
+
+type ElifClause struct {
+	Token *tokenizer.Token
+	Cond  Expr
+	Scope *Scope
+}
+
+type WhileStmt struct {
+	Token *tokenizer.Token
+	Cond  Expr
+	Scope *Scope
+}
+
+// ForStmt is `for (Init; Cond; Step) Scope`. Any clause may be nil, and a
+// missing condition is always true. Init is scoped to the loop.
+type ForStmt struct {
+	Token *tokenizer.Token
+	Init  Stmt
+	Cond  Expr
+	Step  Stmt
+	Scope *Scope
+}
+
+type BreakStmt struct {
+	Token *tokenizer.Token
+}
+
+type ContinueStmt struct {
+	Token *tokenizer.Token
+}
+
+type FnStmt struct {
+	Token  *tokenizer.Token
+	Name   *Ident
+	Params []*Ident
+	Scope  *Scope
+}
+
+type ReturnStmt struct {
+	Token *tokenizer.Token
+	Expr  Expr
+}
+
+type ExprStmt struct {
+	Expr Expr
+}
+
+// BadStmt stands in for a statement that failed to parse.
+type BadStmt struct {
+	From *tokenizer.Token
+	To   *tokenizer.Token
+}
+
+// IntLit is an integer or character literal. Its value is Token.Int.
+type IntLit struct {
+	Token *tokenizer.Token
+}
+
+// StrLit is a string literal. Value holds its bytes with the escapes decoded.
+type StrLit struct {
+	Token *tokenizer.Token
+	Value string
+}
+
+type Ident struct {
+	Token *tokenizer.Token
+}
+
+type BinaryExpr struct {
+	Op    *tokenizer.Token
+	Left  Expr
+	Right Expr
+}
+
+type UnaryExpr struct {
+	Op      *tokenizer.Token
+	Operand Expr
+}
+
+type CallExpr struct {
+	Name *Ident
+	Args []Expr
+}
+
+func (p *Prog) Pos() *tokenizer.Token {
+	if len(p.Stmts) > 0 {
+		return p.Stmts[0].Pos()
+	}
+	return p.EOF
+}
+
+func (s *Scope) Pos() *tokenizer.Token        { return s.Token }
+func (s *ExitStmt) Pos() *tokenizer.Token     { return s.Token }
+func (s *PrintStmt) Pos() *tokenizer.Token    { return s.Token }
+func (s *LetStmt) Pos() *tokenizer.Token      { return s.Token }
+func (s *AssignStmt) Pos() *tokenizer.Token   { return s.Ident.Token }
+func (s *OpAssignStmt) Pos() *tokenizer.Token { return s.Ident.Token }
+func (s *IncDecStmt) Pos() *tokenizer.Token   { return s.Ident.Token }
+func (s *IfStmt) Pos() *tokenizer.Token       { return s.Token }
+func (s *WhileStmt) Pos() *tokenizer.Token    { return s.Token }
+func (s *ForStmt) Pos() *tokenizer.Token      { return s.Token }
+func (s *BreakStmt) Pos() *tokenizer.Token    { return s.Token }
+func (s *ContinueStmt) Pos() *tokenizer.Token { return s.Token }
+func (s *FnStmt) Pos() *tokenizer.Token       { return s.Token }
+func (s *ReturnStmt) Pos() *tokenizer.Token   { return s.Token }
+func (s *ExprStmt) Pos() *tokenizer.Token     { return s.Expr.Pos() }
+func (s *BadStmt) Pos() *tokenizer.Token      { return s.From }
+
+func (e *IntLit) Pos() *tokenizer.Token     { return e.Token }
+func (e *StrLit) Pos() *tokenizer.Token     { return e.Token }
+func (e *Ident) Pos() *tokenizer.Token      { return e.Token }
+func (e *BinaryExpr) Pos() *tokenizer.Token { return e.Op }
+func (e *UnaryExpr) Pos() *tokenizer.Token  { return e.Op }
+func (e *CallExpr) Pos() *tokenizer.Token   { return e.Name.Token }
+
+func (*Scope) stmtNode()        {}
+func (*ExitStmt) stmtNode()     {}
+func (*PrintStmt) stmtNode()    {}
+func (*LetStmt) stmtNode()      {}
+func (*AssignStmt) stmtNode()   {}
+func (*OpAssignStmt) stmtNode() {}
+func (*IncDecStmt) stmtNode()   {}
+func (*IfStmt) stmtNode()       {}
+func (*WhileStmt) stmtNode()    {}
+func (*ForStmt) stmtNode()      {}
+func (*BreakStmt) stmtNode()    {}
+func (*ContinueStmt) stmtNode() {}
+func (*FnStmt) stmtNode()       {}
+func (*ReturnStmt) stmtNode()   {}
+func (*ExprStmt) stmtNode()     {}
+func (*BadStmt) stmtNode()      {}
+
+func (*IntLit) exprNode()     {}
+func (*StrLit) exprNode()     {}
+func (*Ident) exprNode()      {}
+func (*BinaryExpr) exprNode() {}
+func (*UnaryExpr) exprNode()  {}
+func (*CallExpr) exprNode()   {}
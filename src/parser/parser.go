@@ -1,331 +1,473 @@
 package parser
 
 import (
-	"errors"
-	"fmt"
-	"log"
-	"regexp"
-
+	"github.com/arregist97/Hydro-Compiler/diagnostics"
+	"github.com/arregist97/Hydro-Compiler/logger"
 	"github.com/arregist97/Hydro-Compiler/tokenizer"
 )
 
-type NodeStore struct {
-	I     int
-	Block *nodeBlock
+// binaryPrecedence follows C, except that comparisons all share one level.
+var binaryPrecedence = map[tokenizer.Kind]int{
+	tokenizer.Star:      8,
+	tokenizer.Slash:     8,
+	tokenizer.Percent:   8,
+	tokenizer.Plus:      7,
+	tokenizer.Minus:     7,
+	tokenizer.Shl:       6,
+	tokenizer.Shr:       6,
+	tokenizer.Eq:        5,
+	tokenizer.NotEq:     5,
+	tokenizer.Less:      5,
+	tokenizer.LessEq:    5,
+	tokenizer.Greater:   5,
+	tokenizer.GreaterEq: 5,
+	tokenizer.BitAnd:    4,
+	tokenizer.BitXor:    3,
+	tokenizer.BitOr:     2,
+	tokenizer.And:       1,
+	tokenizer.Or:        0,
 }
 
-func (n *NodeStore) AddNode(token *tokenizer.Token, tokenType []string) {
-	store := n.Block
-	store.addNode(token, tokenType, n.I)
-	n.I++
-}
+// maxNesting bounds how deeply scopes and expressions may nest, so that
+// pathological input is reported instead of overflowing the stack.
+const maxNesting = 1000
 
-func (n *NodeStore) GetNode(index int) *TokenTreeNode {
-	store := n.Block
-	return store.getNode(index)
+type parser struct {
+	tokens     []*tokenizer.Token
+	i          int
+	parenDepth int
+	nesting    int
+	diags      *diagnostics.List
+	lastDiag   *diagnostics.Diagnostic
 }
 
-func (n *NodeStore) LinkNodes(j int, right bool, next *TokenTreeNode) {
-	if next == nil {
-		return
-	}
-	store := n.Block
-	store.linkNodes(j, right, next)
-}
+// bailout unwinds the parser after a syntax error has been reported.
+type bailout struct{}
 
-func NewNodeStore() *NodeStore {
-	return &NodeStore{
-		Block: newNodeBlock(),
-		I:     0,
+// Parse builds the syntax tree for a whole program. Syntax errors are
+// reported to diags and the parser resynchronises at the next statement, so
+// the returned tree is still usable (with BadStmt placeholders) on failure.
+func Parse(tokens []*tokenizer.Token, diags *diagnostics.List) (*Prog, error) {
+	p := &parser{tokens: tokens, diags: diags}
+	prog := &Prog{}
+	for {
+		p.skipTerminators()
+		if p.peek().Kind == tokenizer.EOF {
+			prog.EOF = p.next()
+			return prog, diags.Err()
+		}
+		prog.Stmts = append(prog.Stmts, p.parseStmtRecover(false))
 	}
 }
 
-type nodeBlock struct {
-	nodes *[]TokenTreeNode
-	next  *nodeBlock
+func (p *parser) parseStmtRecover(inScope bool) (stmt Stmt) {
+	start := p.i
+	nesting := p.nesting
+	from := p.peek()
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(bailout); !ok {
+				panic(r)
+			}
+			p.nesting = nesting
+			p.synchronize(start)
+			stmt = &BadStmt{From: from, To: p.peekAt(-1)}
+		}
+	}()
+	stmt = p.parseStmt()
+	p.expectTerminator(inScope)
+	return stmt
 }
 
-func newNodeBlock() *nodeBlock {
-	nodes := make([]TokenTreeNode, 100)
-	return &nodeBlock{
-		nodes: &nodes,
+// synchronize skips to the end of the broken statement: the next `\n` or `;`
+// outside of braces, or the `}` closing the enclosing scope. A `;` inside
+// parentheses, such as in a for header, does not end the statement.
+func (p *parser) synchronize(start int) {
+	parens := p.parenDepth
+	p.parenDepth = 0
+	depth := 0
+	for {
+		token := p.peek()
+		switch token.Kind {
+		case tokenizer.EOF:
+			return
+		case tokenizer.NewLine:
+			if depth == 0 {
+				p.next()
+				return
+			}
+		case tokenizer.Semicolon:
+			if depth == 0 && parens <= 0 {
+				p.next()
+				return
+			}
+		case tokenizer.OpenParen:
+			parens++
+		case tokenizer.CloseParen:
+			parens--
+		case tokenizer.OpenCurly:
+			depth++
+		case tokenizer.CloseCurly:
+			if depth == 0 {
+				if p.i == start {
+					p.next()
+				}
+				return
+			}
+			depth--
+		}
+		p.next()
 	}
 }
 
-func (n *nodeBlock) addNode(token *tokenizer.Token, tokenType []string, index int) {
-	nodes := *n.nodes
-	if index < cap(nodes) {
-		nodes[index].TokenType = tokenType
-		nodes[index].Token = token
-	} else if n.next != nil {
-		n.next.addNode(token, tokenType, index-cap(nodes))
-	} else {
-		newBlock := newNodeBlock()
-		n.next = newBlock
-		n.next.addNode(token, tokenType, index-cap(nodes))
+func (p *parser) peek() *tokenizer.Token {
+	for p.parenDepth > 0 && p.i < len(p.tokens) && p.tokens[p.i].Kind == tokenizer.NewLine {
+		p.i++
 	}
-}
-
-func (n *nodeBlock) getNode(index int) *TokenTreeNode {
-	nodes := *n.nodes
-	if index > cap(nodes) {
-		if n.next == nil {
-			log.Fatal("index overflow")
+	if p.i >= len(p.tokens) {
+		if len(p.tokens) > 0 && p.tokens[len(p.tokens)-1].Kind == tokenizer.EOF {
+			return p.tokens[len(p.tokens)-1]
 		}
-		return n.next.getNode(index - cap(nodes))
-	} else {
-		return &nodes[index]
+		return &tokenizer.Token{Kind: tokenizer.EOF, Val: "EOF"}
 	}
+	return p.tokens[p.i]
 }
 
-func (n *nodeBlock) linkNodes(j int, right bool, next *TokenTreeNode) {
-	nodes := *n.nodes
-	if j > cap(nodes) {
-		if n.next == nil {
-			log.Fatal("index overflow")
-		}
-		n.next.linkNodes(j-cap(nodes), right, next)
-	} else {
-		fmt.Printf("Linking %s, %s to %s, %s\n", next.TokenType, next.Token.Val, nodes[j].TokenType, nodes[j].Token.Val)
-		if right {
-			fmt.Println("Right link")
-			nodes[j].Right = next
-		} else {
-			fmt.Println("Left link")
-			nodes[j].Left = next
-		}
-		next.Root = &nodes[j]
+func (p *parser) peekAt(offset int) *tokenizer.Token {
+	if p.i+offset < 0 || p.i+offset >= len(p.tokens) {
+		return &tokenizer.Token{Kind: tokenizer.EOF, Val: "EOF"}
 	}
+	return p.tokens[p.i+offset]
 }
 
-type TokenTreeNode struct {
-	Token     *tokenizer.Token
-	TokenType []string
-	Left      *TokenTreeNode
-	Right     *TokenTreeNode
-	Root      *TokenTreeNode
+func (p *parser) next() *tokenizer.Token {
+	token := p.peek()
+	if p.i < len(p.tokens) {
+		p.i++
+	}
+	return token
 }
 
-func (node *TokenTreeNode) PrintTokenTree() {
-	fmt.Println("Type: ", node.TokenType)
-	fmt.Println("Val: ")
-	node.Token.Print()
-	if node.Left != nil {
-		fmt.Println("{")
-		node.Left.PrintTokenTree()
-		fmt.Println("}")
-	}
-	if node.Right != nil {
-		node.Right.PrintTokenTree()
+func (p *parser) expect(kind tokenizer.Kind, what string) *tokenizer.Token {
+	token := p.peek()
+	if token.Kind != kind {
+		p.fail(token, "expected "+what+", found "+describe(token))
 	}
+	return p.next()
 }
 
-func BuildTokenTree(store *NodeStore, tokens []*tokenizer.Token, inScope bool) *TokenTreeNode {
-	if len(tokens) <= 0 {
-		return nil
-	}
-	token := tokens[0]
-	tokenType, err := validateToken(token)
-	if err != nil {
-		log.Fatal("Error building token tree: ", err)
+func (p *parser) fail(token *tokenizer.Token, msg string) {
+	last := p.lastDiag
+	if last == nil || last.Line != token.Line || last.Column != token.Column {
+		p.diags.Report(token.Line, token.Column, msg)
+		p.lastDiag = p.diags.Diags[p.diags.Len()-1]
 	}
-	var nodeI int = store.I
-	store.AddNode(token, tokenType)
-	fmt.Println("Printing Token")
-	node := store.GetNode(nodeI)
-	node.PrintTokenTree()
-	if node.Token.Val == "{" {
-		fmt.Println("Entering Scope")
-		scope := BuildTokenTree(store, tokens[1:], true)
-		store.LinkNodes(nodeI, false, scope)
-	} else if node.Token.Val == "}" {
-		if !inScope {
-			log.Fatal("Out of scope")
-		}
-		return node
-	} else if node.Token.Val == "(" {
-		fmt.Println("Entering Expr paren")
-		expr, _ := constructExpr(store, tokens[1:], true, 0)
-		store.LinkNodes(nodeI, false, expr)
-	} else if stringInSlice(node.Token.Val, []string{"exit", "=", "if", "elif"}) {
-		fmt.Println("Entering Expr no paren")
-		expr, _ := constructExpr(store, tokens[1:], false, 0)
-		store.LinkNodes(nodeI, false, expr)
-	}
-	offset := store.I - nodeI
-	tokens = tokens[offset:]
-	if stringInSlice(node.Token.Val, []string{"if", "elif", "else"}) {
-		tokens = skipNewLine(tokens)
-	}
-	tree := BuildTokenTree(store, tokens, inScope)
-	store.LinkNodes(nodeI, true, tree)
-	return node
+	panic(bailout{})
 }
 
-func skipNewLine(tokens []*tokenizer.Token) []*tokenizer.Token {
-	fmt.Println("Checking if newline: '" + tokens[0].Val + "'")
-	if len(tokens) <= 0 || tokens[0].Val != "\n" {
-		return tokens
+func (p *parser) enter(token *tokenizer.Token) {
+	p.nesting++
+	if p.nesting > maxNesting {
+		p.fail(token, "nesting too deep")
 	}
+}
 
-	return tokens[1:]
-
+func (p *parser) leave() {
+	p.nesting--
 }
 
-func constructAtom(store *NodeStore, tokens []*tokenizer.Token, paren bool) *TokenTreeNode {
-	if len(tokens) <= 0 {
-		log.Fatal("Unexpected end of file.")
+func (p *parser) skipNewLines() {
+	for p.peek().Kind == tokenizer.NewLine {
+		p.next()
 	}
-	token := tokens[0]
-	tokenType, err := validateToken(token)
-	if err != nil {
-		log.Fatal("Error building token tree: ", err)
-	}
-	if !paren && tokenType[0] != "Expr" {
-		fmt.Println("Exiting Expr no paren")
-		return nil
-	}
-	if len(tokenType) > 1 && tokenType[1] == "ExprOp" {
-		fmt.Println("Exiting Expr detected ExprOp")
-		return nil
-	}
-	if paren && token.Val == "\n" {
-		fmt.Println("Skipping newline inside paren Expr")
-		return constructAtom(store, tokens[1:], paren)
-	}
-	nodeI := store.I
-	store.AddNode(token, tokenType)
-	fmt.Println("Printing Token")
-	node := store.GetNode(nodeI)
-	node.PrintTokenTree()
+}
 
-	if node.Token.Val == "(" {
-		fmt.Println("Entering Expr paren")
-		expr, _ := constructExpr(store, tokens[1:], true, 0)
-		store.LinkNodes(nodeI, false, expr)
-	} else if node.Token.Val == ")" && paren {
-		fmt.Println("Exiting Expr paren")
-		return node
+func (p *parser) skipTerminators() {
+	for p.peek().Kind == tokenizer.NewLine || p.peek().Kind == tokenizer.Semicolon {
+		p.next()
 	}
-	offset := store.I - nodeI
-	tree := constructAtom(store, tokens[offset:], paren)
-	store.LinkNodes(nodeI, true, tree)
-	return node
 }
 
-func isBinExpr(tokens []*tokenizer.Token) bool {
-	if len(tokens) <= 0 {
-		log.Fatal("Unexpected end of file.")
+func (p *parser) expectTerminator(inScope bool) {
+	token := p.peek()
+	switch {
+	case token.Kind == tokenizer.NewLine || token.Kind == tokenizer.Semicolon:
+		p.next()
+	case token.Kind == tokenizer.EOF:
+	case token.Kind == tokenizer.CloseCurly && inScope:
+	default:
+		p.fail(token, "expected end of statement, found "+describe(token))
 	}
-	token := tokens[0]
-	tokenType, err := validateToken(token)
-	fmt.Println("Is BinExpr: " + token.Val)
-	fmt.Println(tokenType)
-	if err != nil {
-		log.Fatal("Error checking for binary expression: ", err)
+}
+
+func (p *parser) parseStmt() Stmt {
+	token := p.peek()
+	logger.Tracef(logger.Parse, "Parsing statement %q at %d:%d", token.Val, token.Line, token.Column)
+	switch token.Kind {
+	case tokenizer.Exit:
+		p.next()
+		return &ExitStmt{Token: token, Expr: p.parseParenExpr()}
+	case tokenizer.Print:
+		p.next()
+		return &PrintStmt{Token: token, Expr: p.parsePrintArg()}
+	case tokenizer.Let:
+		p.next()
+		ident := &Ident{Token: p.expect(tokenizer.Ident, "identifier after let")}
+		p.expect(tokenizer.Assign, "`=`")
+		return &LetStmt{Token: token, Ident: ident, Expr: p.parseExpr(0)}
+	case tokenizer.If:
+		return p.parseIf()
+	case tokenizer.While:
+		p.next()
+		cond := p.parseExpr(0)
+		p.skipNewLines()
+		return &WhileStmt{Token: token, Cond: cond, Scope: p.parseScope()}
+	case tokenizer.For:
+		return p.parseFor()
+	case tokenizer.Break:
+		p.next()
+		return &BreakStmt{Token: token}
+	case tokenizer.Continue:
+		p.next()
+		return &ContinueStmt{Token: token}
+	case tokenizer.Fn:
+		return p.parseFn()
+	case tokenizer.Return:
+		p.next()
+		stmt := &ReturnStmt{Token: token}
+		if !p.atTerminator() {
+			stmt.Expr = p.parseExpr(0)
+		}
+		return stmt
+	case tokenizer.OpenCurly:
+		return p.parseScope()
+	case tokenizer.Ident:
+		if p.peekAt(1).Kind == tokenizer.Assign {
+			ident := &Ident{Token: p.next()}
+			p.next()
+			return &AssignStmt{Ident: ident, Expr: p.parseExpr(0)}
+		}
+		if _, ok := tokenizer.AssignOp(p.peekAt(1).Kind); ok {
+			ident := &Ident{Token: p.next()}
+			op := p.next()
+			return &OpAssignStmt{Ident: ident, Op: op, Expr: p.parseExpr(0)}
+		}
+		if p.peekAt(1).Kind == tokenizer.Inc || p.peekAt(1).Kind == tokenizer.Dec {
+			ident := &Ident{Token: p.next()}
+			return &IncDecStmt{Ident: ident, Op: p.next()}
+		}
+		if p.peekAt(1).Kind == tokenizer.OpenParen {
+			return &ExprStmt{Expr: p.parseCall(&Ident{Token: p.next()})}
+		}
+		p.fail(p.peekAt(1), "expected assignment, `++`/`--` or `(` after "+token.Val+", found "+describe(p.peekAt(1)))
+	case tokenizer.CloseCurly:
+		p.fail(token, "`}` outside of scope")
+	default:
+		p.fail(token, "expected statement, found "+describe(token))
 	}
-	if len(tokenType) > 1 && tokenType[1] == "ExprOp" {
-		fmt.Println("True")
+	return nil
+}
+
+func (p *parser) atTerminator() bool {
+	switch p.peek().Kind {
+	case tokenizer.NewLine, tokenizer.Semicolon, tokenizer.EOF, tokenizer.CloseCurly:
 		return true
 	}
-	fmt.Println("False")
 	return false
-
 }
 
-func constructExpr(store *NodeStore, tokens []*tokenizer.Token, paren bool, minPrec int) (*TokenTreeNode, []*tokenizer.Token) {
-	baseI := store.I
-	fmt.Println("Entering expr")
-	fmt.Println("Paren: ", paren)
-	expr := constructAtom(store, tokens, paren)
-	offset := store.I - baseI
-	if tokens[offset-1].Val == ")" {
-		return expr, tokens[offset:]
-	}
-	tokens = tokens[offset:]
-	firstI := true
+func (p *parser) parseScope() *Scope {
+	scope := &Scope{Token: p.expect(tokenizer.OpenCurly, "`{`")}
+	logger.Tracef(logger.Parse, "Entering scope at %d:%d", scope.Token.Line, scope.Token.Column)
+	p.enter(scope.Token)
 	for {
-		if !isBinExpr(tokens) {
-			break
-		}
-		token := tokens[0]
-		tokenType, _ := validateToken(token)
-		currPrec := 0
-		if token.Val == "*" || token.Val == "/" {
-			currPrec = 1
-		}
-		if currPrec < minPrec {
-			break
+		p.skipTerminators()
+		token := p.peek()
+		if token.Kind == tokenizer.CloseCurly {
+			scope.End = p.next()
+			p.leave()
+			return scope
 		}
-		opI := store.I
-		store.AddNode(token, tokenType)
-		opNode := store.GetNode(opI)
-		currPrec = currPrec + 1
-		tokens = tokens[1:]
-		expr2, updatedTokens := constructExpr(store, tokens, paren, currPrec)
-		tokens = updatedTokens
-		if firstI {
-			store.LinkNodes(opI, false, expr)
-			store.LinkNodes(opI, true, expr2)
-		} else {
-			store.LinkNodes(opI, true, expr)
-			store.LinkNodes(opI, false, expr2)
+		if token.Kind == tokenizer.EOF {
+			p.fail(token, "expected `}`")
 		}
-		expr = opNode
-		firstI = false
+		scope.Stmts = append(scope.Stmts, p.parseStmtRecover(true))
 	}
-	return expr, tokens
 }
 
-func validateToken(token *tokenizer.Token) ([]string, error) {
-	var statements = []string{"exit", "let", "if"}
-	var ifPreds = []string{"elif", "else"}
-	var expressionOperators = []string{"+", "*", "-", "/"}
-	var paren = []string{"(", ")"}
-	var statementTerminators = []string{"\n", ";", "EOF"}
-	var digitCheck = regexp.MustCompile(`^[0-9]+$`)
-	var varCheck = regexp.MustCompile(`\b[_a-zA-Z][_a-zA-Z0-9]*\b`)
+func (p *parser) parseFor() *ForStmt {
+	stmt := &ForStmt{Token: p.next()}
+	p.expect(tokenizer.OpenParen, "`(` after for")
+	p.parenDepth++
+	if p.peek().Kind != tokenizer.Semicolon {
+		stmt.Init = p.parseForClause(true)
+	}
+	p.expect(tokenizer.Semicolon, "`;` after for initializer")
+	if p.peek().Kind != tokenizer.Semicolon {
+		stmt.Cond = p.parseExpr(0)
+	}
+	p.expect(tokenizer.Semicolon, "`;` after for condition")
+	if p.peek().Kind != tokenizer.CloseParen {
+		stmt.Step = p.parseForClause(false)
+	}
+	p.expect(tokenizer.CloseParen, "`)` after for clauses")
+	p.parenDepth--
+	p.skipNewLines()
+	stmt.Scope = p.parseScope()
+	return stmt
+}
 
-	token.Print()
+// parseForClause parses the initializer or step of a for loop, which may be
+// an assignment or a call, and for the initializer a let.
+func (p *parser) parseForClause(allowLet bool) Stmt {
+	token := p.peek()
+	if token.Kind == tokenizer.Ident || (allowLet && token.Kind == tokenizer.Let) {
+		return p.parseStmt()
+	}
+	if allowLet {
+		p.fail(token, "expected let, assignment or call in for initializer, found "+describe(token))
+	}
+	p.fail(token, "expected assignment or call in for step, found "+describe(token))
+	return nil
+}
 
-	if stringInSlice(token.Val, expressionOperators) {
-		return []string{"Expr", "ExprOp"}, nil
-	}
-	if stringInSlice(token.Val, ifPreds) {
-		return []string{"ifPred"}, nil
-	}
-	if token.Val == "=" {
-		return []string{"Stmt", "StmtOp"}, nil
-	}
-	if stringInSlice(token.Val, statementTerminators) {
-		return []string{"Stmt", "StmtTm"}, nil
-	}
-	if stringInSlice(token.Val, statements) {
-		return []string{"Stmt"}, nil
-	}
-	if stringInSlice(token.Val, paren) {
-		return []string{"Expr"}, nil
-	}
-	if token.Val == "{" {
-		return []string{"Stmt", "Scope"}, nil
+func (p *parser) parseIf() *IfStmt {
+	stmt := &IfStmt{Token: p.next()}
+	stmt.Cond = p.parseExpr(0)
+	p.skipNewLines()
+	stmt.Scope = p.parseScope()
+	for {
+		start := p.i
+		p.skipNewLines()
+		token := p.peek()
+		if token.Kind == tokenizer.Elif {
+			p.next()
+			elif := &ElifClause{Token: token, Cond: p.parseExpr(0)}
+			p.skipNewLines()
+			elif.Scope = p.parseScope()
+			stmt.Elifs = append(stmt.Elifs, elif)
+		} else if token.Kind == tokenizer.Else {
+			p.next()
+			p.skipNewLines()
+			stmt.Else = p.parseScope()
+			return stmt
+		} else {
+			p.i = start
+			return stmt
+		}
 	}
-	if token.Val == "}" {
-		return []string{"Stmt", "ScopeTm"}, nil
+}
+
+func (p *parser) parseFn() *FnStmt {
+	stmt := &FnStmt{Token: p.next()}
+	stmt.Name = &Ident{Token: p.expect(tokenizer.Ident, "function name")}
+	p.expect(tokenizer.OpenParen, "`(` after function name")
+	p.parenDepth++
+	for p.peek().Kind != tokenizer.CloseParen {
+		stmt.Params = append(stmt.Params, &Ident{Token: p.expect(tokenizer.Ident, "parameter name")})
+		if p.peek().Kind != tokenizer.Comma {
+			break
+		}
+		p.next()
 	}
-	if digitCheck.MatchString(token.Val) {
-		return []string{"Expr", "Term", "intLit"}, nil
+	p.expect(tokenizer.CloseParen, "`)` after parameters")
+	p.parenDepth--
+	p.skipNewLines()
+	stmt.Scope = p.parseScope()
+	return stmt
+}
+
+func (p *parser) parseParenExpr() Expr {
+	p.expect(tokenizer.OpenParen, "`(`")
+	p.parenDepth++
+	expr := p.parseExpr(0)
+	p.expect(tokenizer.CloseParen, "`)`")
+	p.parenDepth--
+	return expr
+}
+
+// parsePrintArg parses the argument of print, which unlike other expressions
+// may be a string literal.
+func (p *parser) parsePrintArg() Expr {
+	p.expect(tokenizer.OpenParen, "`(`")
+	p.parenDepth++
+	var expr Expr
+	if token := p.peek(); token.Kind == tokenizer.StrLit {
+		p.next()
+		value, err := tokenizer.Unquote(token.Val)
+		if err != nil {
+			p.fail(token, err.Error())
+		}
+		expr = &StrLit{Token: token, Value: value}
+	} else {
+		expr = p.parseExpr(0)
 	}
-	if varCheck.MatchString(token.Val) {
-		return []string{"Expr", "Term", "ident"}, nil
+	p.expect(tokenizer.CloseParen, "`)`")
+	p.parenDepth--
+	return expr
+}
+
+func (p *parser) parseExpr(minPrec int) Expr {
+	expr := p.parseAtom()
+	for {
+		op := p.peek()
+		prec, ok := binaryPrecedence[op.Kind]
+		if !ok || prec < minPrec {
+			return expr
+		}
+		p.next()
+		right := p.parseExpr(prec + 1)
+		expr = &BinaryExpr{Op: op, Left: expr, Right: right}
 	}
-	return []string{}, errors.New("Unable to identify token: `" + token.Val + "`")
 }
 
-func stringInSlice(a string, list []string) bool {
-	for _, b := range list {
-		if b == a {
-			return true
+func (p *parser) parseAtom() Expr {
+	token := p.peek()
+	p.enter(token)
+	defer p.leave()
+	switch token.Kind {
+	case tokenizer.IntLit, tokenizer.CharLit:
+		return &IntLit{Token: p.next()}
+	case tokenizer.Ident:
+		ident := &Ident{Token: p.next()}
+		if p.peek().Kind == tokenizer.OpenParen {
+			return p.parseCall(ident)
 		}
+		return ident
+	case tokenizer.OpenParen:
+		return p.parseParenExpr()
+	case tokenizer.Not, tokenizer.Minus, tokenizer.BitNot:
+		p.next()
+		return &UnaryExpr{Op: token, Operand: p.parseAtom()}
+	}
+	p.fail(token, "expected expression, found "+describe(token))
+	return nil
+}
+
+func (p *parser) parseCall(name *Ident) *CallExpr {
+	call := &CallExpr{Name: name}
+	p.expect(tokenizer.OpenParen, "`(`")
+	p.parenDepth++
+	for p.peek().Kind != tokenizer.CloseParen {
+		call.Args = append(call.Args, p.parseExpr(0))
+		if p.peek().Kind != tokenizer.Comma {
+			break
+		}
+		p.next()
 	}
-	return false
+	p.expect(tokenizer.CloseParen, "`)` after call arguments")
+	p.parenDepth--
+	return call
+}
+
+func describe(token *tokenizer.Token) string {
+	switch token.Kind {
+	case tokenizer.NewLine:
+		return "end of line"
+	case tokenizer.EOF:
+		return "end of file"
+	case tokenizer.StrLit:
+		return "string " + token.Val
+	}
+	return "`" + token.Val + "`"
 }
@@ -0,0 +1,332 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arregist97/Hydro-Compiler/diagnostics"
+	"github.com/arregist97/Hydro-Compiler/tokenizer"
+)
+
+func parse(src string) (*Prog, *diagnostics.List, error) {
+	diags := diagnostics.NewList("test.hy", src)
+	tokens, err := tokenizer.Tokenize(src, diags)
+	if err != nil {
+		return nil, diags, err
+	}
+	prog, err := Parse(tokens, diags)
+	return prog, diags, err
+}
+
+func dump(node Node) string {
+	var sb strings.Builder
+	Fprint(&sb, node)
+	return sb.String()
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"precedence", "exit(1 + 2 * 3)", `
+Prog
+  ExitStmt
+    BinaryExpr +
+      IntLit 1
+      BinaryExpr *
+        IntLit 2
+        IntLit 3
+`},
+		{"left associative", "let x = 8 - 4 - 2", `
+Prog
+  LetStmt x
+    BinaryExpr -
+      BinaryExpr -
+        IntLit 8
+        IntLit 4
+      IntLit 2
+`},
+		{"parens", "let x = (1 + 2) * 3", `
+Prog
+  LetStmt x
+    BinaryExpr *
+      BinaryExpr +
+        IntLit 1
+        IntLit 2
+      IntLit 3
+`},
+		{"compound assignment", "x *= y + 1\nx++\ny--", `
+Prog
+  OpAssignStmt x *=
+    BinaryExpr +
+      Ident y
+      IntLit 1
+  IncDecStmt x ++
+  IncDecStmt y --
+`},
+		{"for", "for (let i = 0; i < n; i++) {\nf(i)\n}", `
+Prog
+  ForStmt
+    Init
+      LetStmt i
+        IntLit 0
+    Cond
+      BinaryExpr <
+        Ident i
+        Ident n
+    Step
+      IncDecStmt i ++
+    Scope
+      ExprStmt
+        CallExpr f
+          Ident i
+`},
+		{"for without clauses", "for (\n;\n;\n) {}", `
+Prog
+  ForStmt
+    Scope
+`},
+		{"for step only", "for (; ; i++) {}", `
+Prog
+  ForStmt
+    Step
+      IncDecStmt i ++
+    Scope
+`},
+		{"bitwise precedence", "let x = a | b ^ c & d == e << 1 + f", `
+Prog
+  LetStmt x
+    BinaryExpr |
+      Ident a
+      BinaryExpr ^
+        Ident b
+        BinaryExpr &
+          Ident c
+          BinaryExpr ==
+            Ident d
+            BinaryExpr <<
+              Ident e
+              BinaryExpr +
+                IntLit 1
+                Ident f
+`},
+		{"bitwise not", "exit(~a >> 2 && b)", `
+Prog
+  ExitStmt
+    BinaryExpr &&
+      BinaryExpr >>
+        UnaryExpr ~
+          Ident a
+        IntLit 2
+      Ident b
+`},
+		{"unary minus", "let x = -a * -(1 - 2) % 3", `
+Prog
+  LetStmt x
+    BinaryExpr %
+      BinaryExpr *
+        UnaryExpr -
+          Ident a
+        UnaryExpr -
+          BinaryExpr -
+            IntLit 1
+            IntLit 2
+      IntLit 3
+`},
+		{"logical below comparison", "let x = !a || b < 1 && c", `
+Prog
+  LetStmt x
+    BinaryExpr ||
+      UnaryExpr !
+        Ident a
+      BinaryExpr &&
+        BinaryExpr <
+          Ident b
+          IntLit 1
+        Ident c
+`},
+		{"assign and call", "x = f(1, y)\nf()", `
+Prog
+  AssignStmt x
+    CallExpr f
+      IntLit 1
+      Ident y
+  ExprStmt
+    CallExpr f
+`},
+		{"if elif else", "if a {\n} elif b {\n}\nelse { exit(1) }", `
+Prog
+  IfStmt
+    Ident a
+    Scope
+    Elif
+      Ident b
+      Scope
+    Else
+      Scope
+        ExitStmt
+          IntLit 1
+`},
+		{"while", "while (i) { break; continue }", `
+Prog
+  WhileStmt
+    Ident i
+    Scope
+      BreakStmt
+      ContinueStmt
+`},
+		{"fn", "fn add(a, b) {\nreturn a + b\n}\nfn f() { return }", `
+Prog
+  FnStmt add(a, b)
+    Scope
+      ReturnStmt
+        BinaryExpr +
+          Ident a
+          Ident b
+  FnStmt f()
+    Scope
+      ReturnStmt
+`},
+		{"print", "print(x - 1)", `
+Prog
+  PrintStmt
+    BinaryExpr -
+      Ident x
+      IntLit 1
+`},
+		{"char literal", "exit('a' + 0x1F)", `
+Prog
+  ExitStmt
+    BinaryExpr +
+      IntLit 'a'
+      IntLit 0x1F
+`},
+		{"print string", `print("a\tb\x21\n")`, `
+Prog
+  PrintStmt
+    StrLit "a\tb!\n"
+`},
+		{"newlines inside parens", "exit((1 +\n 2))", `
+Prog
+  ExitStmt
+    BinaryExpr +
+      IntLit 1
+      IntLit 2
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prog, diags, err := parse(tt.src)
+			if err != nil {
+				t.Fatalf("parse(%q) failed:\n%s", tt.src, diags)
+			}
+			want := strings.TrimPrefix(tt.want, "\n")
+			if got := dump(prog); got != want {
+				t.Errorf("parse(%q)\n got:\n%s\nwant:\n%s", tt.src, got, want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"let = 1", []string{"1:5: error: expected identifier after let, found `=`"}},
+		{"exit 1", []string{"1:6: error: expected `(`, found `1`"}},
+		{"let x = ", []string{"1:9: error: expected expression, found end of file"}},
+		{"}", []string{"1:1: error: `}` outside of scope"}},
+		{"for (exit(1); ; ) {}", []string{"1:6: error: expected let, assignment or call in for initializer, found `exit`"}},
+		{"for (; ; let i = 1) {}", []string{"1:10: error: expected assignment or call in for step, found `let`"}},
+		{"for (let i = 0, i < 1; ) {}", []string{"1:15: error: expected `;` after for initializer, found `,`"}},
+		{"for i = 0 {}", []string{"1:5: error: expected `(` after for, found `i`"}},
+		{`let x = "a"`, []string{"1:9: error: expected expression, found string \"a\""}},
+		{`print("a" + 1)`, []string{"1:11: error: expected `)`, found `+`"}},
+		{"x 1", []string{"1:3: error: expected assignment, `++`/`--` or `(` after x, found `1`"}},
+		{"let x = 1 2", []string{"1:11: error: expected end of statement, found `2`"}},
+		{"{\nlet x = 1", []string{"2:10: error: expected `}`"}},
+		// Every broken statement is reported, not just the first.
+		{"let = 1\nexit(1)\nlet y 2\nexit(+)", []string{
+			"1:5: error: expected identifier after let, found `=`",
+			"3:7: error: expected `=`, found `2`",
+			"4:6: error: expected expression, found `+`",
+		}},
+	}
+	for _, tt := range tests {
+		_, diags, err := parse(tt.src)
+		if err == nil {
+			t.Errorf("parse(%q) succeeded, want %q", tt.src, tt.want)
+			continue
+		}
+		var got []string
+		for _, d := range diags.Diags {
+			got = append(got, strings.TrimPrefix(strings.SplitN(diags.Format(d), "\n", 2)[0], "test.hy:"))
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("parse(%q)\n got: %q\nwant: %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestParseNestingLimit(t *testing.T) {
+	tests := map[string]string{
+		"parens": "exit(" + strings.Repeat("(", 2*maxNesting) + "1" + strings.Repeat(")", 2*maxNesting+1) + ")",
+		"not":    "exit(" + strings.Repeat("!", 2*maxNesting) + "1)",
+		"scopes": strings.Repeat("{", 2*maxNesting) + strings.Repeat("}", 2*maxNesting),
+	}
+	for name, src := range tests {
+		_, diags, err := parse(src)
+		if err == nil {
+			t.Errorf("%s: parse succeeded, want nesting error", name)
+			continue
+		}
+		if got := diags.Diags[0].Msg; got != "nesting too deep" {
+			t.Errorf("%s: first error %q, want %q", name, got, "nesting too deep")
+		}
+	}
+}
+
+func TestParseRecoversTree(t *testing.T) {
+	prog, _, err := parse("let = 1\n{\nexit(\n}\nlet y = 2")
+	if err == nil {
+		t.Fatal("parse succeeded, want errors")
+	}
+	want := "Prog\n  BadStmt\n  Scope\n    BadStmt\n  LetStmt y\n    IntLit 2\n"
+	if got := dump(prog); got != want {
+		t.Errorf("recovered tree\n got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// FuzzParse checks that the parser reports syntax errors instead of crashing
+// and always returns a tree that can be printed.
+func FuzzParse(f *testing.F) {
+	files, _ := filepath.Glob("../../testing/*.hy")
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(src))
+	}
+	f.Add("let = 1\n{\nexit(\n}\nlet y = 2")
+	f.Fuzz(func(t *testing.T, src string) {
+		diags := diagnostics.NewList("fuzz.hy", src)
+		tokens, err := tokenizer.Tokenize(src, diags)
+		if err != nil {
+			return
+		}
+		prog, err := Parse(tokens, diags)
+		if (err == nil) != (diags.Len() == 0) {
+			t.Fatalf("err = %v with %d diagnostics", err, diags.Len())
+		}
+		if prog == nil {
+			t.Fatal("Parse returned a nil tree")
+		}
+		dump(prog)
+	})
+}
@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func Print(node Node) {
+	Fprint(os.Stdout, node)
+}
+
+func Fprint(w io.Writer, node Node) {
+	fprint(w, node, 0)
+}
+
+func fprint(w io.Writer, node Node, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch n := node.(type) {
+	case *Prog:
+		fmt.Fprintln(w, indent+"Prog")
+		for _, stmt := range n.Stmts {
+			fprint(w, stmt, depth+1)
+		}
+	case *Scope:
+		fmt.Fprintln(w, indent+"Scope")
+		for _, stmt := range n.Stmts {
+			fprint(w, stmt, depth+1)
+		}
+	case *ExitStmt:
+		fmt.Fprintln(w, indent+"ExitStmt")
+		fprint(w, n.Expr, depth+1)
+	case *PrintStmt:
+		fmt.Fprintln(w, indent+"PrintStmt")
+		fprint(w, n.Expr, depth+1)
+	case *LetStmt:
+		fmt.Fprintln(w, indent+"LetStmt "+n.Ident.Token.Val)
+		fprint(w, n.Expr, depth+1)
+	case *AssignStmt:
+		fmt.Fprintln(w, indent+"AssignStmt "+n.Ident.Token.Val)
+		fprint(w, n.Expr, depth+1)
+	case *OpAssignStmt:
+		fmt.Fprintln(w, indent+"OpAssignStmt "+n.Ident.Token.Val+" "+n.Op.Val)
+		fprint(w, n.Expr, depth+1)
+	case *IncDecStmt:
+		fmt.Fprintln(w, indent+"IncDecStmt "+n.Ident.Token.Val+" "+n.Op.Val)
+	case *IfStmt:
+		fmt.Fprintln(w, indent+"IfStmt")
+		fprint(w, n.Cond, depth+1)
+		fprint(w, n.Scope, depth+1)
+		for _, elif := range n.Elifs {
+			fmt.Fprintln(w, indent+"  Elif")
+			fprint(w, elif.Cond, depth+2)
+			fprint(w, elif.Scope, depth+2)
+		}
+		if n.Else != nil {
+			fmt.Fprintln(w, indent+"  Else")
+			fprint(w, n.Else, depth+2)
+		}
+	case *WhileStmt:
+		fmt.Fprintln(w, indent+"WhileStmt")
+		fprint(w, n.Cond, depth+1)
+		fprint(w, n.Scope, depth+1)
+	case *ForStmt:
+		fmt.Fprintln(w, indent+"ForStmt")
+		if n.Init != nil {
+			fmt.Fprintln(w, indent+"  Init")
+			fprint(w, n.Init, depth+2)
+		}
+		if n.Cond != nil {
+			fmt.Fprintln(w, indent+"  Cond")
+			fprint(w, n.Cond, depth+2)
+		}
+		if n.Step != nil {
+			fmt.Fprintln(w, indent+"  Step")
+			fprint(w, n.Step, depth+2)
+		}
+		fprint(w, n.Scope, depth+1)
+	case *BreakStmt:
+		fmt.Fprintln(w, indent+"BreakStmt")
+	case *ContinueStmt:
+		fmt.Fprintln(w, indent+"ContinueStmt")
+	case *FnStmt:
+		params := make([]string, len(n.Params))
+		for i, param := range n.Params {
+			params[i] = param.Token.Val
+		}
+		fmt.Fprintln(w, indent+"FnStmt "+n.Name.Token.Val+"("+strings.Join(params, ", ")+")")
+		fprint(w, n.Scope, depth+1)
+	case *ReturnStmt:
+		fmt.Fprintln(w, indent+"ReturnStmt")
+		if n.Expr != nil {
+			fprint(w, n.Expr, depth+1)
+		}
+	case *ExprStmt:
+		fmt.Fprintln(w, indent+"ExprStmt")
+		fprint(w, n.Expr, depth+1)
+	case *BadStmt:
+		fmt.Fprintln(w, indent+"BadStmt")
+	case *IntLit:
+		fmt.Fprintln(w, indent+"IntLit "+n.Token.Val)
+	case *StrLit:
+		fmt.Fprintf(w, "%sStrLit %q\n", indent, n.Value)
+	case *Ident:
+		fmt.Fprintln(w, indent+"Ident "+n.Token.Val)
+	case *BinaryExpr:
+		fmt.Fprintln(w, indent+"BinaryExpr "+n.Op.Val)
+		fprint(w, n.Left, depth+1)
+		fprint(w, n.Right, depth+1)
+	case *UnaryExpr:
+		fmt.Fprintln(w, indent+"UnaryExpr "+n.Op.Val)
+		fprint(w, n.Operand, depth+1)
+	case *CallExpr:
+		fmt.Fprintln(w, indent+"CallExpr "+n.Name.Token.Val)
+		for _, arg := range n.Args {
+			fprint(w, arg, depth+1)
+		}
+	default:
+		fmt.Fprintf(w, "%s%T\n", indent, node)
+	}
+}
@@ -0,0 +1,267 @@
+package tokenizer
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+type Kind int
+
+const (
+	Invalid Kind = iota
+	EOF
+	NewLine
+	Semicolon
+	Comma
+	OpenParen
+	CloseParen
+	OpenCurly
+	CloseCurly
+	IntLit
+	CharLit
+	StrLit
+	Ident
+	Exit
+	Print
+	Let
+	If
+	Elif
+	Else
+	While
+	For
+	Break
+	Continue
+	Fn
+	Return
+	Assign
+	PlusAssign
+	MinusAssign
+	StarAssign
+	SlashAssign
+	PercentAssign
+	Inc
+	Dec
+	Plus
+	Minus
+	Star
+	Slash
+	Percent
+	Eq
+	NotEq
+	Less
+	LessEq
+	Greater
+	GreaterEq
+	And
+	Or
+	Not
+	BitAnd
+	BitOr
+	BitXor
+	BitNot
+	Shl
+	Shr
+)
+
+var kindNames = [...]string{
+	Invalid:       "Invalid",
+	EOF:           "EOF",
+	NewLine:       "NewLine",
+	Semicolon:     "Semicolon",
+	Comma:         "Comma",
+	OpenParen:     "OpenParen",
+	CloseParen:    "CloseParen",
+	OpenCurly:     "OpenCurly",
+	CloseCurly:    "CloseCurly",
+	IntLit:        "IntLit",
+	CharLit:       "CharLit",
+	StrLit:        "StrLit",
+	Ident:         "Ident",
+	Exit:          "Exit",
+	Print:         "Print",
+	Let:           "Let",
+	If:            "If",
+	Elif:          "Elif",
+	Else:          "Else",
+	While:         "While",
+	For:           "For",
+	Break:         "Break",
+	Continue:      "Continue",
+	Fn:            "Fn",
+	Return:        "Return",
+	Assign:        "Assign",
+	PlusAssign:    "PlusAssign",
+	MinusAssign:   "MinusAssign",
+	StarAssign:    "StarAssign",
+	SlashAssign:   "SlashAssign",
+	PercentAssign: "PercentAssign",
+	Inc:           "Inc",
+	Dec:           "Dec",
+	Plus:          "Plus",
+	Minus:         "Minus",
+	Star:          "Star",
+	Slash:         "Slash",
+	Percent:       "Percent",
+	Eq:            "Eq",
+	NotEq:         "NotEq",
+	Less:          "Less",
+	LessEq:        "LessEq",
+	Greater:       "Greater",
+	GreaterEq:     "GreaterEq",
+	And:           "And",
+	Or:            "Or",
+	Not:           "Not",
+	BitAnd:        "BitAnd",
+	BitOr:         "BitOr",
+	BitXor:        "BitXor",
+	BitNot:        "BitNot",
+	Shl:           "Shl",
+	Shr:           "Shr",
+}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return "Invalid"
+	}
+	return kindNames[k]
+}
+
+var fixedKinds = map[string]Kind{
+	"\n":       NewLine,
+	";":        Semicolon,
+	",":        Comma,
+	"(":        OpenParen,
+	")":        CloseParen,
+	"{":        OpenCurly,
+	"}":        CloseCurly,
+	"exit":     Exit,
+	"print":    Print,
+	"let":      Let,
+	"if":       If,
+	"elif":     Elif,
+	"else":     Else,
+	"while":    While,
+	"for":      For,
+	"break":    Break,
+	"continue": Continue,
+	"fn":       Fn,
+	"return":   Return,
+	"=":        Assign,
+	"+=":       PlusAssign,
+	"-=":       MinusAssign,
+	"*=":       StarAssign,
+	"/=":       SlashAssign,
+	"%=":       PercentAssign,
+	"++":       Inc,
+	"--":       Dec,
+	"+":        Plus,
+	"-":        Minus,
+	"*":        Star,
+	"/":        Slash,
+	"%":        Percent,
+	"==":       Eq,
+	"!=":       NotEq,
+	"<":        Less,
+	"<=":       LessEq,
+	">":        Greater,
+	">=":       GreaterEq,
+	"&&":       And,
+	"||":       Or,
+	"!":        Not,
+	"&":        BitAnd,
+	"|":        BitOr,
+	"^":        BitXor,
+	"~":        BitNot,
+	"<<":       Shl,
+	">>":       Shr,
+}
+
+var assignOps = map[Kind]Kind{
+	PlusAssign:    Plus,
+	MinusAssign:   Minus,
+	StarAssign:    Star,
+	SlashAssign:   Slash,
+	PercentAssign: Percent,
+}
+
+// AssignOp returns the operator applied by a compound assignment such as
+// `+=`, and false for any other kind.
+func AssignOp(k Kind) (Kind, bool) {
+	op, ok := assignOps[k]
+	return op, ok
+}
+
+func kindOf(val string) (Kind, error) {
+	if kind, ok := fixedKinds[val]; ok {
+		return kind, nil
+	}
+	if isNumber(val) {
+		return IntLit, nil
+	}
+	if isIdent(val) {
+		return Ident, nil
+	}
+	return Invalid, errors.New("unable to identify token `" + val + "`")
+}
+
+// isNumber reports whether val is a decimal, `0x` hexadecimal or `0b` binary
+// integer. Underscores may separate digits.
+func isNumber(val string) bool {
+	digits, base := splitBase(val)
+	for i := 0; i < len(digits); i++ {
+		c := digits[i]
+		if c == '_' && i > 0 && i < len(digits)-1 && digits[i-1] != '_' {
+			continue
+		}
+		if digitVal(c) >= base {
+			return false
+		}
+	}
+	return len(digits) > 0
+}
+
+// parseNumber returns the value of a literal accepted by isNumber. Literals
+// up to 2^64-1 are allowed and wrap to negative values when used as signed.
+func parseNumber(val string) (uint64, error) {
+	digits, base := splitBase(val)
+	return strconv.ParseUint(strings.ReplaceAll(digits, "_", ""), base, 64)
+}
+
+func splitBase(val string) (string, int) {
+	if len(val) > 2 && val[0] == '0' {
+		switch val[1] {
+		case 'x', 'X':
+			return val[2:], 16
+		case 'b', 'B':
+			return val[2:], 2
+		}
+	}
+	return val, 10
+}
+
+func digitVal(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10
+	}
+	return 16
+}
+
+func isIdent(val string) bool {
+	for i := 0; i < len(val); i++ {
+		c := val[i]
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+			continue
+		}
+		if i > 0 && c >= '0' && c <= '9' {
+			continue
+		}
+		return false
+	}
+	return len(val) > 0
+}
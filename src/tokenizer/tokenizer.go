@@ -1,224 +1,255 @@
 package tokenizer
 
 import (
-	"errors"
 	"fmt"
-	"log"
 	"strconv"
 	"unicode/utf8"
+
+	"github.com/arregist97/Hydro-Compiler/diagnostics"
+	"github.com/arregist97/Hydro-Compiler/logger"
 )
 
 type Token struct {
+	Kind   Kind
 	Val    string
 	Line   int
 	Column int
+	Start  int
+	End    int
+	// Int is the value of an IntLit or CharLit.
+	Int uint64
 }
 
 func (token *Token) Print() {
+	fmt.Println("Kind: " + token.Kind.String())
 	fmt.Println("Val: " + token.Val)
 	fmt.Println("Line: " + strconv.Itoa(token.Line))
 	fmt.Println("Col: " + strconv.Itoa(token.Column))
 
 }
 
-func Tokenize(content string, tokens []*Token) []*Token {
-	return recTokenize(content, 1, 1, tokens)
+func (token *Token) String() string {
+	return fmt.Sprintf("%d:%d %s %q", token.Line, token.Column, token.Kind, token.Val)
 }
 
-func recTokenize(content string, line int, col int, tokens []*Token) []*Token {
-	var tokenVal string
-	var skippedLines int
-	var tokenCol int
-	var tokenSize int
-	var updatedContent string
-	var err error
+const tokenBlockSize = 1024
+
+// scanner walks the source once, keeping only a byte offset and the start of
+// the current line. Token values are slices of the source and tokens are
+// allocated in blocks, so the cost per token is constant.
+type scanner struct {
+	src       string
+	offset    int
+	line      int
+	lineStart int
+	block     []Token
+	tokens    []*Token
+	diags     *diagnostics.List
+}
 
-	tokenVal, skippedLines, tokenCol, tokenSize, updatedContent, err = buildToken(content, col, 0)
-	if err != nil {
-		log.Fatal("Error reading file:", err)
+func Tokenize(content string, diags *diagnostics.List) ([]*Token, error) {
+	s := &scanner{
+		src:    content,
+		line:   1,
+		tokens: make([]*Token, 0, len(content)/4+1),
+		diags:  diags,
 	}
+	s.scan()
+	return s.tokens, diags.Err()
+}
 
-	fmt.Println(tokenVal + "/end")
-	if len(tokenVal) > 0 {
-		token := Token{
-			Val:    tokenVal,
-			Line:   line,
-			Column: tokenCol,
+func (s *scanner) scan() {
+	for s.offset < len(s.src) {
+		c := s.src[s.offset]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			s.offset++
+		case c == '\n':
+			s.emit(NewLine, s.offset, s.offset+1)
+			s.offset++
+			s.line++
+			s.lineStart = s.offset
+		case c == '/' && s.peek(1) == '/':
+			s.skipLineComment()
+		case c == '/' && s.peek(1) == '*':
+			s.skipBlockComment()
+		case c == '"':
+			s.scanQuoted('"', StrLit)
+		case c == '\'':
+			s.scanChar()
+		case isTwoRuneOperator(c, s.peek(1)):
+			s.emitVal(s.offset, s.offset+2)
+			s.offset += 2
+		case isEndOfToken(c):
+			s.emitVal(s.offset, s.offset+1)
+			s.offset++
+		default:
+			s.scanWord()
 		}
-		fmt.Println("Token Created!")
-		token.Print()
-		tokens = append(tokens, &token)
-	}
-	if skippedLines <= 0 && tokenVal != "\n" {
-		col = col + tokenSize
-	} else if tokenVal == "\n" {
-		line++
-		col = 1
-	} else {
-		line = line + skippedLines
-		col = tokenSize
 	}
-
-	if len(content) > 0 {
-		return recTokenize(updatedContent, line, col, tokens)
-	}
-
-	token := Token{
-		Val:    "EOF",
-		Line:   line,
-		Column: col,
-	}
-	fmt.Println("Token Created!")
-	token.Print()
-	tokens = append(tokens, &token)
-
-	return tokens
+	s.emit(EOF, s.offset, s.offset)
 }
 
-func buildToken(content string, col int, i int) (string, int, int, int, string, error) {
-	var updatedToken string
-	var updatedContent string
-	var updatedSize int
-	var colPlace int
-	var tokenSize int
-	var tokenCol = col
-	var skippedLines = 0
-	var err error = nil
-
-	if len(content) <= i {
-		return "", 0, 0, 0, "", err
+func (s *scanner) peek(n int) byte {
+	if s.offset+n >= len(s.src) {
+		return 0
 	}
+	return s.src[s.offset+n]
+}
 
-	r, size := utf8.DecodeRuneInString(content[i:])
-	if r == utf8.RuneError {
-		if size == 1 {
-			return "", 0, 0, size, "", errors.New("could not recognize token " + content[i:i+1])
-		} else {
-			return "", 0, 0, size, "", errors.New("empty decode error")
+func (s *scanner) scanWord() {
+	start := s.offset
+	for s.offset < len(s.src) {
+		c := s.src[s.offset]
+		if c == '\t' || c == '\r' || isEndOfToken(c) {
+			break
 		}
-	}
-
-	peek, _ := utf8.DecodeRuneInString(content[i+size:])
-	if peek == utf8.RuneError {
-		updatedToken = string(r)
-		updatedContent = content[i+size:]
-		return updatedToken, skippedLines, col, size, updatedContent, err
-	}
-
-	if r == ' ' && i == 0 {
-		updatedToken, skippedLines, tokenCol, tokenSize, updatedContent, err = buildToken(content[size:], col+size, 0)
-		updatedSize = tokenSize + size
-	} else if r == '/' && (peek == '/' || peek == '*') {
-		lnCmt := false
-		if peek == '/' {
-			lnCmt = true
+		if c < utf8.RuneSelf {
+			s.offset++
+			continue
 		}
-		updatedToken = ""
-		updatedContent, skippedLines, colPlace, err = skipComment(content, i+2, lnCmt)
-		updatedSize = colPlace + size
-	} else if r == '\n' {
-		updatedToken = "\n"
-		updatedContent, skippedLines, colPlace, err = skipBlankSpace(content, i+1)
-		updatedSize = colPlace + size
-	} else if isEndOfToken(r) || isEndOfToken(peek) {
-		updatedToken = string(r)
-		updatedContent = content[i+size:]
-		updatedSize = size
-	} else {
-		var token string
-		token, skippedLines, tokenCol, tokenSize, updatedContent, err = buildToken(content, col, i+size)
-		updatedToken = string(r) + token
-		updatedSize = tokenSize + size
-	}
-	return updatedToken, skippedLines, tokenCol, updatedSize, updatedContent, err
-}
-
-func skipBlankSpace(content string, i int) (string, int, int, error) {
-	var skippedLines = 0
-	var columnPlace = 1
-	var updatedContent string
-	var err error = nil
-
-	r, size := utf8.DecodeRuneInString(content[i:])
-	if r == utf8.RuneError {
-		if size == 1 {
-			return "", 0, 0, errors.New("could not recognize token " + content[i:i+1])
-		} else {
-			return "", 0, 0, errors.New("empty decode error")
+		r, size := utf8.DecodeRuneInString(s.src[s.offset:])
+		if r == utf8.RuneError && size == 1 {
+			s.report(s.offset, "invalid UTF-8 encoding")
 		}
+		s.offset += size
 	}
+	s.emitVal(start, s.offset)
+}
 
-	if r == ' ' {
-		updatedContent, skippedLines, columnPlace, err = skipBlankSpace(content, i+1)
-		return updatedContent, skippedLines, columnPlace + size, err
-	}
-	if r == '\n' {
-		updatedContent, skippedLines, _, err = skipBlankSpace(content, i+1)
-		columnPlace = 1
-		return updatedContent, skippedLines + 1, columnPlace, err
+// scanQuoted emits a string or character literal with its quotes. The
+// escapes are only checked here; Unquote decodes them.
+func (s *scanner) scanQuoted(quote byte, kind Kind) *Token {
+	start := s.offset
+	s.offset++
+	for s.offset < len(s.src) {
+		switch s.src[s.offset] {
+		case quote:
+			s.offset++
+			return s.emit(kind, start, s.offset)
+		case '\n':
+			s.report(start, "unterminated "+literalName[kind])
+			return nil
+		case '\\':
+			_, size, err := unescape(s.src[s.offset:])
+			if err != nil {
+				s.report(s.offset, err.Error())
+			}
+			s.offset += size
+		default:
+			s.offset++
+		}
 	}
-
-	return content[i:], skippedLines, columnPlace, nil
-
+	s.report(start, "unterminated "+literalName[kind])
+	return nil
 }
 
-func skipComment(content string, i int, lineComment bool) (string, int, int, error) {
-	var skippedLines = 0
-	var columnPlace int
-	var updatedContent string
-	var err error = nil
+var literalName = map[Kind]string{
+	StrLit:  "string literal",
+	CharLit: "character literal",
+}
 
-	r, size := utf8.DecodeRuneInString(content[i:])
-	if r == utf8.RuneError {
-		if size == 1 {
-			return "", 0, size, errors.New("could not recognize token " + content[i:i+1])
-		} else {
-			return "", 0, size, errors.New("empty decode error")
-		}
+func (s *scanner) scanChar() {
+	start := s.offset
+	token := s.scanQuoted('\'', CharLit)
+	if token == nil {
+		return
 	}
-
-	if lineComment && r == '\n' {
-		updatedContent := content[i:]
-		return updatedContent, skippedLines, size, nil
+	val, err := Unquote(token.Val)
+	if err != nil {
+		return
 	}
+	if len(val) != 1 {
+		s.report(start, "character literal must hold exactly one byte")
+		return
+	}
+	token.Int = uint64(val[0])
+}
 
-	if r != '*' || lineComment {
-		updatedContent, skippedLines, columnPlace, err = skipComment(content, i+1, lineComment)
-		if r == '\n' {
-			skippedLines++
-			columnPlace = 1
-			return updatedContent, skippedLines, columnPlace, err
-		}
-		return updatedContent, skippedLines, columnPlace + size, err
+func (s *scanner) skipLineComment() {
+	for s.offset < len(s.src) && s.src[s.offset] != '\n' {
+		s.offset++
 	}
+}
 
-	peek, s := utf8.DecodeRuneInString(content[i+size:])
-	size = size + s
-	if peek == utf8.RuneError {
-		if s == 1 {
-			return "", 0, size, errors.New("could not recognize token " + content[i:i+1])
-		} else {
-			return "", 0, size, errors.New("empty decode error")
+func (s *scanner) skipBlockComment() {
+	start := s.offset
+	startLine := s.line
+	startLineStart := s.lineStart
+	s.offset += 2
+	for s.offset < len(s.src) {
+		if s.src[s.offset] == '*' && s.peek(1) == '/' {
+			s.offset += 2
+			return
+		}
+		if s.src[s.offset] == '\n' {
+			s.line++
+			s.lineStart = s.offset + 1
 		}
+		s.offset++
 	}
+	s.diags.Report(startLine, start-startLineStart+1, "unterminated block comment")
+}
 
-	if peek == '/' {
-		updatedContent := content[i+size:]
-		return updatedContent, skippedLines, size, nil
+func (s *scanner) emitVal(start int, end int) {
+	val := s.src[start:end]
+	kind, err := kindOf(val)
+	if err != nil {
+		s.report(start, err.Error())
+		return
+	}
+	token := s.emit(kind, start, end)
+	if kind == IntLit {
+		token.Int, err = parseNumber(val)
+		if err != nil {
+			s.report(start, "integer literal `"+val+"` does not fit in 64 bits")
+		}
 	}
+}
 
-	updatedContent, skippedLines, columnPlace, err = skipComment(content, i+1, lineComment)
-	return updatedContent, skippedLines, columnPlace + size, err
+func (s *scanner) emit(kind Kind, start int, end int) *Token {
+	if len(s.block) == cap(s.block) {
+		s.block = make([]Token, 0, tokenBlockSize)
+	}
+	val := s.src[start:end]
+	if kind == EOF {
+		val = "EOF"
+	}
+	s.block = append(s.block, Token{
+		Kind:   kind,
+		Val:    val,
+		Line:   s.line,
+		Column: start - s.lineStart + 1,
+		Start:  start,
+		End:    end,
+	})
+	token := &s.block[len(s.block)-1]
+	s.tokens = append(s.tokens, token)
+	if logger.Enabled(logger.Tokens) {
+		logger.Tracef(logger.Tokens, "%s", token)
+	}
+	return token
+}
 
+func (s *scanner) report(offset int, msg string) {
+	s.diags.Report(s.line, offset-s.lineStart+1, msg)
 }
 
-func isEndOfToken(a rune) bool {
-	var endOfTokenRunes = [...]rune{'(', ')', '{', '}', ' ', '\n', '=', '+', '*', '-', '/'}
+func isEndOfToken(a byte) bool {
+	switch a {
+	case '(', ')', '{', '}', ' ', '\n', '"', '\'', '=', '+', '*', '-', '/', '%', '<', '>', '!', '&', '|', '^', '~', ',', ';':
+		return true
+	}
+	return false
+}
 
-	for _, b := range endOfTokenRunes {
-		if b == a {
+func isTwoRuneOperator(a byte, b byte) bool {
+	if b == '=' {
+		switch a {
+		case '=', '!', '<', '>', '+', '-', '*', '/', '%':
 			return true
 		}
+		return false
 	}
-	return false
+	return a == b && (a == '&' || a == '|' || a == '<' || a == '>' || a == '+' || a == '-')
 }
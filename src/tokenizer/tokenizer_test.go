@@ -0,0 +1,220 @@
+package tokenizer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arregist97/Hydro-Compiler/diagnostics"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"empty", "", []string{"EOF EOF"}},
+		{"let", "let x = 12", []string{"Let let", "Ident x", "Assign =", "IntLit 12", "EOF EOF"}},
+		{"no spaces", "exit(x+1)", []string{"Exit exit", "OpenParen (", "Ident x", "Plus +", "IntLit 1", "CloseParen )", "EOF EOF"}},
+		{"two rune operators", "a<=b>=c==d!=e&&f||g", []string{
+			"Ident a", "LessEq <=", "Ident b", "GreaterEq >=", "Ident c", "Eq ==", "Ident d",
+			"NotEq !=", "Ident e", "And &&", "Ident f", "Or ||", "Ident g", "EOF EOF",
+		}},
+		{"single rune operators", "!a < b > c", []string{"Not !", "Ident a", "Less <", "Ident b", "Greater >", "Ident c", "EOF EOF"}},
+		{"arithmetic", "-a%b*-1", []string{"Minus -", "Ident a", "Percent %", "Ident b", "Star *", "Minus -", "IntLit 1", "EOF EOF"}},
+		{"keywords", "exit print let if elif else while for break continue fn return", []string{
+			"Exit exit", "Print print", "Let let", "If if", "Elif elif", "Else else", "While while", "For for", "Break break",
+			"Continue continue", "Fn fn", "Return return", "EOF EOF",
+		}},
+		{"keyword prefix is an ident", "lettuce iffy", []string{"Ident lettuce", "Ident iffy", "EOF EOF"}},
+		{"newlines and semicolons", "a;\nb\r\n", []string{"Ident a", "Semicolon ;", "NewLine \n", "Ident b", "NewLine \n", "EOF EOF"}},
+		{"line comment", "a // b c\nd", []string{"Ident a", "NewLine \n", "Ident d", "EOF EOF"}},
+		{"block comment", "a /* b\n c */ d", []string{"Ident a", "Ident d", "EOF EOF"}},
+		{"strings", `print("a b // c")"\"x\\"`, []string{
+			"Print print", "OpenParen (", `StrLit "a b // c"`, "CloseParen )", `StrLit "\"x\\"`, "EOF EOF",
+		}},
+		{"bitwise", "~a&b|c^d<<1>>e&&f", []string{
+			"BitNot ~", "Ident a", "BitAnd &", "Ident b", "BitOr |", "Ident c", "BitXor ^", "Ident d",
+			"Shl <<", "IntLit 1", "Shr >>", "Ident e", "And &&", "Ident f", "EOF EOF",
+		}},
+		{"compound assignment", "a+=1;b-=c*=d/=e%=f++;g--", []string{
+			"Ident a", "PlusAssign +=", "IntLit 1", "Semicolon ;", "Ident b", "MinusAssign -=", "Ident c",
+			"StarAssign *=", "Ident d", "SlashAssign /=", "Ident e", "PercentAssign %=", "Ident f", "Inc ++",
+			"Semicolon ;", "Ident g", "Dec --", "EOF EOF",
+		}},
+		{"literals", "0x1F+'a'-1_000", []string{"IntLit 0x1F", "Plus +", "CharLit 'a'", "Minus -", "IntLit 1_000", "EOF EOF"}},
+		{"call", "f(a, b)", []string{"Ident f", "OpenParen (", "Ident a", "Comma ,", "Ident b", "CloseParen )", "EOF EOF"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Tokenize(tt.src, diagnostics.NewList("test.hy", tt.src))
+			if err != nil {
+				t.Fatalf("Tokenize(%q) failed: %v", tt.src, err)
+			}
+			got := make([]string, len(tokens))
+			for i, token := range tokens {
+				got[i] = token.Kind.String() + " " + token.Val
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Tokenize(%q)\n got: %q\nwant: %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeLiterals(t *testing.T) {
+	tests := []struct {
+		src  string
+		kind Kind
+		want uint64
+	}{
+		{"0", IntLit, 0},
+		{"007", IntLit, 7},
+		{"1_000_000", IntLit, 1000000},
+		{"0x1F", IntLit, 31},
+		{"0Xff_ff", IntLit, 65535},
+		{"0b1010", IntLit, 10},
+		{"18446744073709551615", IntLit, 1<<64 - 1},
+		{"0xFFFF_FFFF_FFFF_FFFF", IntLit, 1<<64 - 1},
+		{"'a'", CharLit, 'a'},
+		{"' '", CharLit, ' '},
+		{`'\n'`, CharLit, '\n'},
+		{`'\''`, CharLit, '\''},
+		{`'"'`, CharLit, '"'},
+		{`'\x7f'`, CharLit, 0x7f},
+	}
+	for _, tt := range tests {
+		tokens, err := Tokenize(tt.src, diagnostics.NewList("test.hy", tt.src))
+		if err != nil {
+			t.Errorf("Tokenize(%q) failed: %v", tt.src, err)
+			continue
+		}
+		if tokens[0].Kind != tt.kind || tokens[0].Int != tt.want {
+			t.Errorf("Tokenize(%q) = %s %d, want %s %d", tt.src, tokens[0].Kind, tokens[0].Int, tt.kind, tt.want)
+		}
+	}
+}
+
+func TestTokenizePositions(t *testing.T) {
+	src := "let x = 1\n\texit(x)"
+	tokens, err := Tokenize(src, diagnostics.NewList("test.hy", src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"1:1", "1:5", "1:7", "1:9", "1:10", "2:2", "2:6", "2:7", "2:8"}
+	for i, w := range want {
+		got := fmt.Sprintf("%d:%d", tokens[i].Line, tokens[i].Column)
+		if got != w {
+			t.Errorf("token %d (%s) at %s, want %s", i, tokens[i].Val, got, w)
+		}
+		if src[tokens[i].Start:tokens[i].End] != tokens[i].Val {
+			t.Errorf("token %d spans %q, want %q", i, src[tokens[i].Start:tokens[i].End], tokens[i].Val)
+		}
+	}
+}
+
+func TestTokenizeErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"let x = 1a", "test.hy:1:9: error: unable to identify token `1a`"},
+		{"let x = 1 /* open", "test.hy:1:11: error: unterminated block comment"},
+		{"a\nb $c", "test.hy:2:3: error: unable to identify token `$c`"},
+		{"x = \xff", "test.hy:1:5: error: invalid UTF-8 encoding"},
+		{`print("abc`, "test.hy:1:7: error: unterminated string literal"},
+		{"print(\"a\nb\")", "test.hy:1:7: error: unterminated string literal"},
+		{`print("a\qb")`, "test.hy:1:9: error: unknown escape sequence `\\q`"},
+		{"x = 18446744073709551616", "test.hy:1:5: error: integer literal `18446744073709551616` does not fit in 64 bits"},
+		{"x = 0x1_0000_0000_0000_0000", "test.hy:1:5: error: integer literal `0x1_0000_0000_0000_0000` does not fit in 64 bits"},
+		{"x = 0b102", "test.hy:1:5: error: unable to identify token `0b102`"},
+		{"x = 1__0", "test.hy:1:5: error: unable to identify token `1__0`"},
+		{"x = 1_", "test.hy:1:5: error: unable to identify token `1_`"},
+		{"x = 0x", "test.hy:1:5: error: unable to identify token `0x`"},
+		{"x = ''", "test.hy:1:5: error: character literal must hold exactly one byte"},
+		{"x = 'ab'", "test.hy:1:5: error: character literal must hold exactly one byte"},
+		{"x = 'a", "test.hy:1:5: error: unterminated character literal"},
+		{`print("\x4g")`, "test.hy:1:8: error: invalid escape sequence `\\x`, want two hex digits"},
+	}
+	for _, tt := range tests {
+		diags := diagnostics.NewList("test.hy", tt.src)
+		_, err := Tokenize(tt.src, diags)
+		if err == nil {
+			t.Errorf("Tokenize(%q) succeeded, want %q", tt.src, tt.want)
+			continue
+		}
+		if got := diags.Format(diags.Diags[0]); !strings.HasPrefix(got, tt.want+"\n") {
+			t.Errorf("Tokenize(%q) first error\n got: %q\nwant: %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+// FuzzTokenize checks that any input either tokenizes or reports an error,
+// and that every token points back into the source.
+func FuzzTokenize(f *testing.F) {
+	files, _ := filepath.Glob("../../testing/*.hy")
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(src))
+	}
+	f.Add(benchChunk)
+	f.Fuzz(func(t *testing.T, src string) {
+		diags := diagnostics.NewList("fuzz.hy", src)
+		tokens, err := Tokenize(src, diags)
+		if (err == nil) != (diags.Len() == 0) {
+			t.Fatalf("err = %v with %d diagnostics", err, diags.Len())
+		}
+		if len(tokens) == 0 || tokens[len(tokens)-1].Kind != EOF {
+			t.Fatal("token stream does not end with EOF")
+		}
+		for _, token := range tokens[:len(tokens)-1] {
+			if token.Start < 0 || token.End > len(src) || src[token.Start:token.End] != token.Val {
+				t.Fatalf("token %s does not match the source at %d:%d", token, token.Start, token.End)
+			}
+		}
+		for _, d := range diags.Diags {
+			diags.Format(d)
+		}
+	})
+}
+
+const benchChunk = `let x = 10 - 2 * 3 / 2 // line comment
+if (x >= 4 && !(x == 7)) {
+  /* block
+     comment */
+  x = x + 1
+}
+`
+
+func benchSource(size int) string {
+	var sb strings.Builder
+	sb.Grow(size + len(benchChunk))
+	for sb.Len() < size {
+		sb.WriteString(benchChunk)
+	}
+	return sb.String()
+}
+
+// BenchmarkTokenize runs over inputs of growing size. Time per op should grow
+// in proportion to the input, i.e. MB/s stays flat across the sub-benchmarks.
+func BenchmarkTokenize(b *testing.B) {
+	for _, mb := range []int{1, 4, 16} {
+		src := benchSource(mb << 20)
+		b.Run(fmt.Sprintf("%dMB", mb), func(b *testing.B) {
+			b.SetBytes(int64(len(src)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, err := Tokenize(src, diagnostics.NewList("bench.hy", src))
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package tokenizer
+
+import (
+	"errors"
+	"strings"
+)
+
+// Unquote returns the bytes a string or character literal stands for. The
+// literal must include its quotes.
+func Unquote(lit string) (string, error) {
+	if len(lit) < 2 || (lit[0] != '"' && lit[0] != '\'') || lit[len(lit)-1] != lit[0] {
+		return "", errors.New("invalid literal " + lit)
+	}
+	body := lit[1 : len(lit)-1]
+	var b strings.Builder
+	for i := 0; i < len(body); {
+		if body[i] != '\\' {
+			b.WriteByte(body[i])
+			i++
+			continue
+		}
+		c, size, err := unescape(body[i:])
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(c)
+		i += size
+	}
+	return b.String(), nil
+}
+
+// unescape decodes the escape sequence at the start of s, which begins with a
+// backslash, and returns the byte and how much of s it used.
+func unescape(s string) (byte, int, error) {
+	if len(s) < 2 {
+		return 0, 1, errors.New("unterminated escape sequence")
+	}
+	switch s[1] {
+	case 'n':
+		return '\n', 2, nil
+	case 't':
+		return '\t', 2, nil
+	case 'r':
+		return '\r', 2, nil
+	case '0':
+		return 0, 2, nil
+	case '\\', '"', '\'':
+		return s[1], 2, nil
+	case 'x':
+		if len(s) >= 4 && digitVal(s[2]) < 16 && digitVal(s[3]) < 16 {
+			return byte(digitVal(s[2])<<4 | digitVal(s[3])), 4, nil
+		}
+		return 0, 2, errors.New("invalid escape sequence `\\x`, want two hex digits")
+	}
+	if s[1] == '\n' {
+		return 0, 1, errors.New("unknown escape sequence `\\`")
+	}
+	return 0, 2, errors.New("unknown escape sequence `\\" + s[1:2] + "`")
+}
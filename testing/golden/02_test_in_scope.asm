@@ -0,0 +1,17 @@
+global _start
+_start:
+  mov    rax, 1
+  push   rax
+  mov    rax, 2
+  push   rax
+  mov    rax, 3
+  push   rax
+  push   QWORD [rsp + 0]
+  mov    rax, 60
+  pop    rdi
+  syscall
+  add    rsp, 8
+  add    rsp, 8
+  mov    rax, 60
+  mov    rdi, 0
+  syscall
\ No newline at end of file
@@ -0,0 +1,49 @@
+global _start
+_start:
+  mov    rax, 2
+  push   rax
+  mov    rax, 0
+  push   rax
+  mov    rax, 0
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label0
+  push   QWORD [rsp + 8]
+  mov    rax, 60
+  pop    rdi
+  syscall
+  jmp    label1
+label0:
+  push   QWORD [rsp + 0]
+  pop    rax
+  test   rax, rax
+  jz     label2
+  mov    rax, 7
+  push   rax
+  mov    rax, 60
+  pop    rdi
+  syscall
+  jmp    label1
+label2:
+  mov    rax, 0
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label3
+  mov    rax, 1
+  push   rax
+  mov    rax, 60
+  pop    rdi
+  syscall
+  jmp    label1
+label3:
+  mov    rax, 69
+  push   rax
+  mov    rax, 60
+  pop    rdi
+  syscall
+label1:
+  mov    rax, 60
+  mov    rdi, 0
+  syscall
\ No newline at end of file
@@ -0,0 +1,28 @@
+global _start
+_start:
+  mov    rax, 10
+  push   rax
+  mov    rax, 3
+  push   rax
+  pop    rbx
+  pop    rax
+  sub    rax, rbx
+  push   rax
+  mov    rax, 0
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label0
+  mov    rax, 0
+  push   rax
+  mov    rax, 60
+  pop    rdi
+  syscall
+label0:
+  push   QWORD [rsp + 0]
+  mov    rax, 60
+  pop    rdi
+  syscall
+  mov    rax, 60
+  mov    rdi, 0
+  syscall
\ No newline at end of file
@@ -0,0 +1,26 @@
+global _start
+_start:
+  mov    rax, 3
+  push   rax
+label0:
+  push   QWORD [rsp + 0]
+  pop    rax
+  test   rax, rax
+  jz     label1
+  mov    rax, 4
+  push   rax
+  mov    rax, 5
+  push   rax
+  add    rsp, 16
+  jmp    label1
+  add    rsp, 8
+  add    rsp, 8
+  jmp    label0
+label1:
+  push   QWORD [rsp + 0]
+  mov    rax, 60
+  pop    rdi
+  syscall
+  mov    rax, 60
+  mov    rdi, 0
+  syscall
\ No newline at end of file
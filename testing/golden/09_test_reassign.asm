@@ -0,0 +1,58 @@
+global _start
+_start:
+  mov    rax, 5
+  push   rax
+  mov    rax, 0
+  push   rax
+label0:
+  push   QWORD [rsp + 8]
+  pop    rax
+  test   rax, rax
+  jz     label1
+  push   QWORD [rsp + 8]
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  sub    rax, rbx
+  push   rax
+  pop    rax
+  mov    QWORD [rsp + 8], rax
+  push   QWORD [rsp + 8]
+  mov    rax, 2
+  push   rax
+  pop    rbx
+  pop    rax
+  sub    rax, rbx
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label2
+  push   QWORD [rsp + 0]
+  push   QWORD [rsp + 16]
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  pop    rax
+  mov    QWORD [rsp + 0], rax
+  jmp    label0
+label2:
+  push   QWORD [rsp + 0]
+  mov    rax, 10
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  pop    rax
+  mov    QWORD [rsp + 0], rax
+  jmp    label0
+label1:
+  push   QWORD [rsp + 0]
+  mov    rax, 60
+  pop    rdi
+  syscall
+  mov    rax, 60
+  mov    rdi, 0
+  syscall
\ No newline at end of file
@@ -0,0 +1,158 @@
+global _start
+_start:
+  mov    rax, 0
+  push   rax
+  mov    rax, 0
+  push   rax
+label0:
+  push   QWORD [rsp + 8]
+  mov    rax, 10
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setl   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label1
+  push   QWORD [rsp + 8]
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  pop    rax
+  mov    QWORD [rsp + 8], rax
+  push   QWORD [rsp + 8]
+  mov    rax, 3
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  sete   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label2
+  jmp    label0
+label2:
+  push   QWORD [rsp + 8]
+  mov    rax, 8
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setge  al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label3
+  jmp    label1
+label3:
+  push   QWORD [rsp + 8]
+  mov    rax, 5
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setne  al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label4
+  push   QWORD [rsp + 0]
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  pop    rax
+  mov    QWORD [rsp + 0], rax
+label4:
+  jmp    label0
+label1:
+  push   QWORD [rsp + 8]
+  mov    rax, 7
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setg   al
+  movzx  rax, al
+  push   rax
+  push   QWORD [rsp + 8]
+  mov    rax, 5
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setle  al
+  movzx  rax, al
+  push   rax
+  mov    rax, 2
+  push   rax
+  pop    rbx
+  pop    rax
+  imul   rax, rbx
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  mov    rax, 1
+  push   rax
+  mov    rax, 2
+  push   rax
+  pop    rbx
+  pop    rax
+  sub    rax, rbx
+  push   rax
+  mov    rax, 3
+  push   rax
+  pop    rbx
+  pop    rax
+  sub    rax, rbx
+  push   rax
+  mov    rax, 0
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setl   al
+  movzx  rax, al
+  push   rax
+  mov    rax, 4
+  push   rax
+  pop    rbx
+  pop    rax
+  imul   rax, rbx
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  push   QWORD [rsp + 8]
+  mov    rax, 10
+  push   rax
+  pop    rbx
+  pop    rax
+  imul   rax, rbx
+  push   rax
+  push   QWORD [rsp + 8]
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  mov    rax, 60
+  pop    rdi
+  syscall
+  mov    rax, 60
+  mov    rdi, 0
+  syscall
\ No newline at end of file
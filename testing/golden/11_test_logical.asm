@@ -0,0 +1,267 @@
+global _start
+_start:
+  mov    rax, 0
+  push   rax
+  mov    rax, 3
+  push   rax
+  mov    rax, 0
+  push   rax
+  push   QWORD [rsp + 8]
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setg   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label1
+  push   QWORD [rsp + 8]
+  mov    rax, 5
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setl   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+label1:
+  setne  al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label0
+  push   QWORD [rsp + 0]
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  pop    rax
+  mov    QWORD [rsp + 0], rax
+label0:
+  push   QWORD [rsp + 16]
+  mov    rax, 0
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setne  al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label3
+  mov    rax, 10
+  push   rax
+  push   QWORD [rsp + 24]
+  pop    rbx
+  pop    rax
+  cqo
+  idiv   rbx
+  push   rax
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setg   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+label3:
+  setne  al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label2
+  push   QWORD [rsp + 0]
+  mov    rax, 10
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  pop    rax
+  mov    QWORD [rsp + 0], rax
+label2:
+  push   QWORD [rsp + 16]
+  mov    rax, 0
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  sete   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jnz    label5
+  mov    rax, 10
+  push   rax
+  push   QWORD [rsp + 24]
+  pop    rbx
+  pop    rax
+  cqo
+  idiv   rbx
+  push   rax
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setg   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+label5:
+  setne  al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label4
+  push   QWORD [rsp + 0]
+  mov    rax, 2
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  pop    rax
+  mov    QWORD [rsp + 0], rax
+label4:
+  push   QWORD [rsp + 16]
+  pop    rax
+  test   rax, rax
+  sete   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label8
+  push   QWORD [rsp + 8]
+  mov    rax, 4
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  sete   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  sete   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+label8:
+  setne  al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jnz    label7
+  push   QWORD [rsp + 16]
+  pop    rax
+  test   rax, rax
+label7:
+  setne  al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label6
+  push   QWORD [rsp + 0]
+  mov    rax, 4
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  pop    rax
+  mov    QWORD [rsp + 0], rax
+label6:
+  push   QWORD [rsp + 8]
+  pop    rax
+  test   rax, rax
+  sete   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  sete   al
+  movzx  rax, al
+  push   rax
+  push   QWORD [rsp + 24]
+  pop    rax
+  test   rax, rax
+  jnz    label9
+  push   QWORD [rsp + 16]
+  pop    rax
+  test   rax, rax
+label9:
+  setne  al
+  movzx  rax, al
+  push   rax
+  mov    rax, 8
+  push   rax
+  pop    rbx
+  pop    rax
+  imul   rax, rbx
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  mov    rax, 0
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label10
+  mov    rax, 1
+  push   rax
+  pop    rax
+  test   rax, rax
+label10:
+  setne  al
+  movzx  rax, al
+  push   rax
+  mov    rax, 16
+  push   rax
+  pop    rbx
+  pop    rax
+  imul   rax, rbx
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  push   QWORD [rsp + 8]
+  mov    rax, 32
+  push   rax
+  pop    rbx
+  pop    rax
+  imul   rax, rbx
+  push   rax
+  push   QWORD [rsp + 8]
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  mov    rax, 60
+  pop    rdi
+  syscall
+  mov    rax, 60
+  mov    rdi, 0
+  syscall
\ No newline at end of file
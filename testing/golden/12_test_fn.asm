@@ -0,0 +1,199 @@
+global _start
+_start:
+  mov    rax, 10
+  push   rax
+  pop    rdi
+  call   fn_fib
+  push   rax
+  sub    rsp, 8
+  push   QWORD [rsp + 8]
+  pop    rdi
+  call   fn_check
+  add    rsp, 8
+  push   rax
+  add    rsp, 8
+  push   QWORD [rsp + 0]
+  mov    rax, 8
+  push   rax
+  mov    rax, 7
+  push   rax
+  mov    rax, 6
+  push   rax
+  mov    rax, 5
+  push   rax
+  mov    rax, 4
+  push   rax
+  mov    rax, 3
+  push   rax
+  mov    rax, 2
+  push   rax
+  mov    rax, 1
+  push   rax
+  pop    rdi
+  pop    rsi
+  pop    rdx
+  pop    rcx
+  pop    r8
+  pop    r9
+  call   fn_sum8
+  add    rsp, 16
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  mov    rax, 100
+  push   rax
+  pop    rbx
+  pop    rax
+  sub    rax, rbx
+  push   rax
+  mov    rax, 60
+  pop    rdi
+  syscall
+  mov    rax, 60
+  mov    rdi, 0
+  syscall
+fn_fib:
+  push   rbp
+  mov    rbp, rsp
+  push   rdi
+  push   QWORD [rsp + 0]
+  mov    rax, 2
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setl   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label0
+  push   QWORD [rsp + 0]
+  pop    rax
+  mov    rsp, rbp
+  pop    rbp
+  ret
+label0:
+  sub    rsp, 8
+  push   QWORD [rsp + 8]
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  sub    rax, rbx
+  push   rax
+  pop    rdi
+  call   fn_fib
+  add    rsp, 8
+  push   rax
+  push   QWORD [rsp + 8]
+  mov    rax, 2
+  push   rax
+  pop    rbx
+  pop    rax
+  sub    rax, rbx
+  push   rax
+  pop    rdi
+  call   fn_fib
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  pop    rax
+  mov    rsp, rbp
+  pop    rbp
+  ret
+  mov    rax, 0
+  mov    rsp, rbp
+  pop    rbp
+  ret
+fn_sum8:
+  push   rbp
+  mov    rbp, rsp
+  push   rdi
+  push   rsi
+  push   rdx
+  push   rcx
+  push   r8
+  push   r9
+  push   QWORD [rbp + 16]
+  push   QWORD [rbp + 24]
+  push   QWORD [rsp + 56]
+  push   QWORD [rsp + 56]
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  push   QWORD [rsp + 48]
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  push   QWORD [rsp + 40]
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  push   QWORD [rsp + 32]
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  push   QWORD [rsp + 24]
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  push   QWORD [rsp + 16]
+  mov    rax, 10
+  push   rax
+  pop    rbx
+  pop    rax
+  imul   rax, rbx
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  push   QWORD [rsp + 8]
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  pop    rax
+  mov    rsp, rbp
+  pop    rbp
+  ret
+  mov    rax, 0
+  mov    rsp, rbp
+  pop    rbp
+  ret
+fn_check:
+  push   rbp
+  mov    rbp, rsp
+  push   rdi
+  push   QWORD [rsp + 0]
+  mov    rax, 55
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setne  al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label1
+  mov    rax, 1
+  push   rax
+  mov    rax, 60
+  pop    rdi
+  syscall
+label1:
+  mov    rax, 0
+  mov    rsp, rbp
+  pop    rbp
+  ret
\ No newline at end of file
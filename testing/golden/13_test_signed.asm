@@ -0,0 +1,182 @@
+global _start
+_start:
+  mov    rax, 5
+  push   rax
+  pop    rax
+  neg    rax
+  push   rax
+  mov    rax, 17
+  push   rax
+  push   QWORD [rsp + 0]
+  push   QWORD [rsp + 16]
+  pop    rbx
+  pop    rax
+  cqo
+  idiv   rbx
+  push   rax
+  push   QWORD [rsp + 8]
+  push   QWORD [rsp + 24]
+  pop    rbx
+  pop    rax
+  cqo
+  idiv   rbx
+  mov    rax, rdx
+  push   rax
+  mov    rax, 7
+  push   rax
+  pop    rax
+  neg    rax
+  push   rax
+  mov    rax, 3
+  push   rax
+  pop    rbx
+  pop    rax
+  cqo
+  idiv   rbx
+  mov    rax, rdx
+  push   rax
+  push   QWORD [rsp + 32]
+  mov    rax, 3
+  push   rax
+  pop    rax
+  neg    rax
+  push   rax
+  pop    rbx
+  pop    rax
+  imul   rax, rbx
+  push   rax
+  push   QWORD [rsp + 40]
+  mov    rax, 0
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setl   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label5
+  push   QWORD [rsp + 24]
+  mov    rax, 3
+  push   rax
+  pop    rax
+  neg    rax
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  sete   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+label5:
+  setne  al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label4
+  push   QWORD [rsp + 16]
+  mov    rax, 2
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  sete   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+label4:
+  setne  al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label3
+  push   QWORD [rsp + 8]
+  mov    rax, 1
+  push   rax
+  pop    rax
+  neg    rax
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  sete   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+label3:
+  setne  al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label2
+  push   QWORD [rsp + 0]
+  mov    rax, 15
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  sete   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+label2:
+  setne  al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label1
+  push   QWORD [rsp + 40]
+  pop    rax
+  neg    rax
+  push   rax
+  mov    rax, 4
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setg   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+label1:
+  setne  al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label0
+  push   QWORD [rsp + 24]
+  mov    rax, 10
+  push   rax
+  pop    rbx
+  pop    rax
+  imul   rax, rbx
+  push   rax
+  push   QWORD [rsp + 24]
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  mov    rax, 60
+  pop    rdi
+  syscall
+label0:
+  mov    rax, 1
+  push   rax
+  mov    rax, 60
+  pop    rdi
+  syscall
+  mov    rax, 60
+  mov    rdi, 0
+  syscall
\ No newline at end of file
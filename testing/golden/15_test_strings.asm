@@ -0,0 +1,111 @@
+global _start
+_start:
+  mov    rax, 0
+  push   rax
+  mov    rax, 1
+  mov    rdi, 1
+  lea    rsi, [rel str0]
+  mov    rdx, 14
+  syscall
+  mov    rax, 1
+  mov    rdi, 1
+  lea    rsi, [rel str1]
+  mov    rdx, 27
+  syscall
+  mov    rax, 1
+  mov    rdi, 1
+  lea    rsi, [rel str2]
+  mov    rdx, 10
+  syscall
+  mov    rax, 1
+  mov    rdi, 1
+  lea    rsi, [rel str3]
+  mov    rdx, 1
+  syscall
+label0:
+  push   QWORD [rsp + 0]
+  mov    rax, 5
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setl   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label1
+  push   QWORD [rsp + 0]
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  pop    rax
+  mov    QWORD [rsp + 0], rax
+  jmp    label0
+label1:
+  mov    rax, 1
+  mov    rdi, 1
+  lea    rsi, [rel str4]
+  mov    rdx, 8
+  syscall
+  push   QWORD [rsp + 0]
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 1
+  mov    rdi, 1
+  lea    rsi, [rel str0]
+  mov    rdx, 14
+  syscall
+  push   QWORD [rsp + 0]
+  mov    rax, 60
+  pop    rdi
+  syscall
+  mov    rax, 60
+  mov    rdi, 0
+  syscall
+rt_print_int:
+  push   rbp
+  mov    rbp, rsp
+  sub    rsp, 32
+  lea    rsi, [rbp - 1]
+  mov    BYTE [rsi], 10
+  call   rt_itoa
+  mov    rsi, rax
+  mov    rdx, rbp
+  sub    rdx, rsi
+  mov    rax, 1
+  mov    rdi, 1
+  syscall
+  mov    rsp, rbp
+  pop    rbp
+  ret
+rt_itoa:
+  mov    rax, rdi
+  mov    rcx, 10
+  test   rax, rax
+  jns    rt_itoa_digit
+  neg    rax
+rt_itoa_digit:
+  xor    rdx, rdx
+  div    rcx
+  add    dl, 48
+  dec    rsi
+  mov    BYTE [rsi], dl
+  test   rax, rax
+  jnz    rt_itoa_digit
+  test   rdi, rdi
+  jns    rt_itoa_done
+  dec    rsi
+  mov    BYTE [rsi], 45
+rt_itoa_done:
+  mov    rax, rsi
+  ret
+section .rodata
+str0: db "Hello, world!", 10
+str1: db 34, "quoted", 34, 9, "and tabbed \ back", 10
+str2: db "two", 10, "lines", 10
+str3: db 10
+str4: db "count: ", 10
\ No newline at end of file
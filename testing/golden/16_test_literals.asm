@@ -0,0 +1,105 @@
+global _start
+_start:
+  mov    rax, 97
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 10
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 39
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 65
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 31
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 10
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 1000000
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 18446744073709551615
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 9223372036854775808
+  push   rax
+  pop    rax
+  neg    rax
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 122
+  push   rax
+  mov    rax, 97
+  push   rax
+  pop    rbx
+  pop    rax
+  sub    rax, rbx
+  push   rax
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  push   QWORD [rsp + 0]
+  mov    rax, 16
+  push   rax
+  pop    rbx
+  pop    rax
+  sub    rax, rbx
+  push   rax
+  mov    rax, 60
+  pop    rdi
+  syscall
+  mov    rax, 60
+  mov    rdi, 0
+  syscall
+rt_print_int:
+  push   rbp
+  mov    rbp, rsp
+  sub    rsp, 32
+  lea    rsi, [rbp - 1]
+  mov    BYTE [rsi], 10
+  call   rt_itoa
+  mov    rsi, rax
+  mov    rdx, rbp
+  sub    rdx, rsi
+  mov    rax, 1
+  mov    rdi, 1
+  syscall
+  mov    rsp, rbp
+  pop    rbp
+  ret
+rt_itoa:
+  mov    rax, rdi
+  mov    rcx, 10
+  test   rax, rax
+  jns    rt_itoa_digit
+  neg    rax
+rt_itoa_digit:
+  xor    rdx, rdx
+  div    rcx
+  add    dl, 48
+  dec    rsi
+  mov    BYTE [rsi], dl
+  test   rax, rax
+  jnz    rt_itoa_digit
+  test   rdi, rdi
+  jns    rt_itoa_done
+  dec    rsi
+  mov    BYTE [rsi], 45
+rt_itoa_done:
+  mov    rax, rsi
+  ret
\ No newline at end of file
@@ -0,0 +1,222 @@
+global _start
+_start:
+  mov    rax, 10
+  push   rax
+  push   QWORD [rsp + 0]
+  mov    rax, 255
+  push   rax
+  pop    rbx
+  pop    rax
+  and    rax, rbx
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  push   QWORD [rsp + 0]
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  or     rax, rbx
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  push   QWORD [rsp + 0]
+  mov    rax, 11
+  push   rax
+  pop    rbx
+  pop    rax
+  xor    rax, rbx
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 5
+  push   rax
+  pop    rax
+  not    rax
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 5
+  push   rax
+  mov    rax, 3
+  push   rax
+  pop    rbx
+  pop    rax
+  mov    rcx, rbx
+  sal    rax, cl
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 12
+  push   rax
+  pop    rax
+  neg    rax
+  push   rax
+  mov    rax, 2
+  push   rax
+  pop    rbx
+  pop    rax
+  mov    rcx, rbx
+  sar    rax, cl
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 1
+  push   rax
+  mov    rax, 63
+  push   rax
+  pop    rbx
+  pop    rax
+  mov    rcx, rbx
+  sal    rax, cl
+  push   rax
+  pop    rax
+  not    rax
+  push   rax
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  mov    rcx, rbx
+  sar    rax, cl
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 1
+  push   rax
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  mov    rcx, rbx
+  sal    rax, cl
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  push   QWORD [rsp + 0]
+  mov    rax, 2
+  push   rax
+  mov    rax, 2
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  sete   al
+  movzx  rax, al
+  push   rax
+  pop    rbx
+  pop    rax
+  and    rax, rbx
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 0
+  push   rax
+  mov    rax, 240
+  push   rax
+label0:
+  push   QWORD [rsp + 0]
+  mov    rax, 0
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setne  al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label1
+  push   QWORD [rsp + 8]
+  push   QWORD [rsp + 8]
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  and    rax, rbx
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  pop    rax
+  mov    QWORD [rsp + 8], rax
+  push   QWORD [rsp + 0]
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  mov    rcx, rbx
+  sar    rax, cl
+  push   rax
+  pop    rax
+  mov    QWORD [rsp + 0], rax
+  jmp    label0
+label1:
+  push   QWORD [rsp + 8]
+  mov    rax, 4
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  sete   al
+  movzx  rax, al
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  push   QWORD [rsp + 8]
+  mov    rax, 2
+  push   rax
+  pop    rbx
+  pop    rax
+  or     rax, rbx
+  push   rax
+  mov    rax, 60
+  pop    rdi
+  syscall
+  mov    rax, 60
+  mov    rdi, 0
+  syscall
+rt_print_int:
+  push   rbp
+  mov    rbp, rsp
+  sub    rsp, 32
+  lea    rsi, [rbp - 1]
+  mov    BYTE [rsi], 10
+  call   rt_itoa
+  mov    rsi, rax
+  mov    rdx, rbp
+  sub    rdx, rsi
+  mov    rax, 1
+  mov    rdi, 1
+  syscall
+  mov    rsp, rbp
+  pop    rbp
+  ret
+rt_itoa:
+  mov    rax, rdi
+  mov    rcx, 10
+  test   rax, rax
+  jns    rt_itoa_digit
+  neg    rax
+rt_itoa_digit:
+  xor    rdx, rdx
+  div    rcx
+  add    dl, 48
+  dec    rsi
+  mov    BYTE [rsi], dl
+  test   rax, rax
+  jnz    rt_itoa_digit
+  test   rdi, rdi
+  jns    rt_itoa_done
+  dec    rsi
+  mov    BYTE [rsi], 45
+rt_itoa_done:
+  mov    rax, rsi
+  ret
\ No newline at end of file
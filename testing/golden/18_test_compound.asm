@@ -0,0 +1,140 @@
+global _start
+_start:
+  mov    rax, 0
+  push   rax
+  mov    rax, 0
+  push   rax
+label0:
+  push   QWORD [rsp + 0]
+  mov    rax, 10
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setl   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label1
+  inc    QWORD [rsp + 0]
+  jmp    label0
+label1:
+  push   QWORD [rsp + 0]
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 10
+  push   rax
+  pop    rbx
+  add    QWORD [rsp + 8], rbx
+  mov    rax, 3
+  push   rax
+  pop    rbx
+  sub    QWORD [rsp + 8], rbx
+  push   QWORD [rsp + 8]
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 3
+  push   rax
+  pop    rbx
+  mov    rax, QWORD [rsp + 8]
+  imul   rax, rbx
+  mov    QWORD [rsp + 8], rax
+  push   QWORD [rsp + 8]
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 4
+  push   rax
+  pop    rbx
+  mov    rax, QWORD [rsp + 8]
+  cqo
+  idiv   rbx
+  mov    QWORD [rsp + 8], rax
+  push   QWORD [rsp + 8]
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 2
+  push   rax
+  pop    rbx
+  mov    rax, QWORD [rsp + 8]
+  cqo
+  idiv   rbx
+  mov    rax, rdx
+  mov    QWORD [rsp + 8], rax
+  push   QWORD [rsp + 8]
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 0
+  push   rax
+  mov    rax, 10
+  push   rax
+label2:
+  push   QWORD [rsp + 0]
+  mov    rax, 0
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setg   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label3
+  push   QWORD [rsp + 0]
+  pop    rbx
+  add    QWORD [rsp + 8], rbx
+  dec    QWORD [rsp + 0]
+  jmp    label2
+label3:
+  push   QWORD [rsp + 8]
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 13
+  push   rax
+  pop    rbx
+  sub    QWORD [rsp + 8], rbx
+  push   QWORD [rsp + 8]
+  mov    rax, 60
+  pop    rdi
+  syscall
+  mov    rax, 60
+  mov    rdi, 0
+  syscall
+rt_print_int:
+  push   rbp
+  mov    rbp, rsp
+  sub    rsp, 32
+  lea    rsi, [rbp - 1]
+  mov    BYTE [rsi], 10
+  call   rt_itoa
+  mov    rsi, rax
+  mov    rdx, rbp
+  sub    rdx, rsi
+  mov    rax, 1
+  mov    rdi, 1
+  syscall
+  mov    rsp, rbp
+  pop    rbp
+  ret
+rt_itoa:
+  mov    rax, rdi
+  mov    rcx, 10
+  test   rax, rax
+  jns    rt_itoa_digit
+  neg    rax
+rt_itoa_digit:
+  xor    rdx, rdx
+  div    rcx
+  add    dl, 48
+  dec    rsi
+  mov    BYTE [rsi], dl
+  test   rax, rax
+  jnz    rt_itoa_digit
+  test   rdi, rdi
+  jns    rt_itoa_done
+  dec    rsi
+  mov    BYTE [rsi], 45
+rt_itoa_done:
+  mov    rax, rsi
+  ret
\ No newline at end of file
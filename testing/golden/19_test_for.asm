@@ -0,0 +1,234 @@
+global _start
+_start:
+  mov    rax, 3
+  push   rax
+  mov    rax, 0
+  push   rax
+label0:
+  push   QWORD [rsp + 0]
+  push   QWORD [rsp + 16]
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setl   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label2
+  push   QWORD [rsp + 0]
+  pop    rdi
+  call   rt_print_int
+label1:
+  inc    QWORD [rsp + 0]
+  jmp    label0
+label2:
+  add    rsp, 8
+  mov    rax, 0
+  push   rax
+  mov    rax, 1
+  push   rax
+label3:
+  push   QWORD [rsp + 0]
+  mov    rax, 10
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setl   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label5
+  push   QWORD [rsp + 0]
+  mov    rax, 2
+  push   rax
+  pop    rbx
+  pop    rax
+  cqo
+  idiv   rbx
+  mov    rax, rdx
+  push   rax
+  mov    rax, 0
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  sete   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label6
+  jmp    label4
+label6:
+  push   QWORD [rsp + 0]
+  pop    rbx
+  add    QWORD [rsp + 8], rbx
+label4:
+  push   QWORD [rsp + 0]
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  pop    rax
+  mov    QWORD [rsp + 0], rax
+  jmp    label3
+label5:
+  add    rsp, 8
+  push   QWORD [rsp + 0]
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 100
+  push   rax
+  mov    rax, 3
+  push   rax
+label7:
+  push   QWORD [rsp + 0]
+  mov    rax, 12
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setg   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label10
+  jmp    label9
+label10:
+  push   QWORD [rsp + 0]
+  push   QWORD [rsp + 8]
+  pop    rbx
+  pop    rax
+  imul   rax, rbx
+  push   rax
+  push   QWORD [rsp + 8]
+  pop    rdi
+  call   rt_print_int
+  add    rsp, 8
+label8:
+  mov    rax, 3
+  push   rax
+  pop    rbx
+  add    QWORD [rsp + 0], rbx
+  jmp    label7
+label9:
+  add    rsp, 8
+  mov    rax, 0
+  push   rax
+label11:
+  push   QWORD [rsp + 0]
+  mov    rax, 1
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setl   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label13
+label12:
+  inc    QWORD [rsp + 0]
+  jmp    label11
+label13:
+  add    rsp, 8
+  sub    rsp, 8
+  call   fn_seven
+  add    rsp, 8
+  push   rax
+  pop    rdi
+  call   rt_print_int
+  mov    rax, 0
+  push   rax
+label14:
+  push   QWORD [rsp + 0]
+  mov    rax, 17
+  push   rax
+  pop    rbx
+  pop    rax
+  cmp    rax, rbx
+  setl   al
+  movzx  rax, al
+  push   rax
+  pop    rax
+  test   rax, rax
+  jz     label16
+  inc    QWORD [rsp + 0]
+label15:
+  jmp    label14
+label16:
+  push   QWORD [rsp + 0]
+  push   QWORD [rsp + 16]
+  pop    rbx
+  pop    rax
+  add    rax, rbx
+  push   rax
+  mov    rax, 100
+  push   rax
+  pop    rbx
+  pop    rax
+  sub    rax, rbx
+  push   rax
+  mov    rax, 60
+  pop    rdi
+  syscall
+  mov    rax, 60
+  mov    rdi, 0
+  syscall
+fn_seven:
+  push   rbp
+  mov    rbp, rsp
+  mov    rax, 7
+  push   rax
+  pop    rax
+  mov    rsp, rbp
+  pop    rbp
+  ret
+  mov    rax, 0
+  mov    rsp, rbp
+  pop    rbp
+  ret
+rt_print_int:
+  push   rbp
+  mov    rbp, rsp
+  sub    rsp, 32
+  lea    rsi, [rbp - 1]
+  mov    BYTE [rsi], 10
+  call   rt_itoa
+  mov    rsi, rax
+  mov    rdx, rbp
+  sub    rdx, rsi
+  mov    rax, 1
+  mov    rdi, 1
+  syscall
+  mov    rsp, rbp
+  pop    rbp
+  ret
+rt_itoa:
+  mov    rax, rdi
+  mov    rcx, 10
+  test   rax, rax
+  jns    rt_itoa_digit
+  neg    rax
+rt_itoa_digit:
+  xor    rdx, rdx
+  div    rcx
+  add    dl, 48
+  dec    rsi
+  mov    BYTE [rsi], dl
+  test   rax, rax
+  jnz    rt_itoa_digit
+  test   rdi, rdi
+  jns    rt_itoa_done
+  dec    rsi
+  mov    BYTE [rsi], 45
+rt_itoa_done:
+  mov    rax, rsi
+  ret
\ No newline at end of file